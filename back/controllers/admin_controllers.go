@@ -12,14 +12,15 @@ type LogController struct {
 
 type MonitoringController struct {
 	MonitoringService *services.MonitoringService
+	LocationService   *services.LocationService
 }
 
 func NewLogController(logService *services.LogService) *LogController {
 	return &LogController{LogService: logService}
 }
 
-func NewMonitoringController(monitoringService *services.MonitoringService) *MonitoringController {
-	return &MonitoringController{MonitoringService: monitoringService}
+func NewMonitoringController(monitoringService *services.MonitoringService, locationService *services.LocationService) *MonitoringController {
+	return &MonitoringController{MonitoringService: monitoringService, LocationService: locationService}
 }
 
 // CreateLogHandler creates a log entry (useful for testing or manual entries)
@@ -67,9 +68,14 @@ func (lc *LogController) FetchLogsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"logs": log})
 }
 
-// FetchMonitoringDataHandler retrieves aggregated monitoring data
+// FetchMonitoringDataHandler retrieves aggregated monitoring data, for one location when location_id is given
 func (mc *MonitoringController) FetchMonitoringDataHandler(c *gin.Context) {
-	data, err := mc.MonitoringService.FetchMonitoringData()
+	locationID, ok := locationQuery(c)
+	if !ok || !checkLocationAccess(c, mc.LocationService, locationID) {
+		return
+	}
+
+	data, err := mc.MonitoringService.FetchMonitoringData(locationID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
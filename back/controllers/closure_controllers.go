@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ClosureController struct {
+	ClosureService     *services.ClosureService
+	ReservationService *services.ReservationService
+}
+
+func NewClosureController(closureService *services.ClosureService, reservationService *services.ReservationService) *ClosureController {
+	return &ClosureController{ClosureService: closureService, ReservationService: reservationService}
+}
+
+// closureInput is the body of closure create and update requests
+type closureInput struct {
+	LocationID     primitive.ObjectID `json:"location_id"` // Empty to close every location
+	StartDate      string             `json:"start_date" binding:"required"`
+	EndDate        string             `json:"end_date"`
+	SpotNumbers    []int              `json:"spot_numbers"`
+	Reason         string             `json:"reason" binding:"required"`
+	CancelExisting bool               `json:"cancel_existing"` // Cancel and notify the reservations falling inside a new closure
+}
+
+// toClosure parses the dates of the input into a closure
+func (in *closureInput) toClosure() (*model.Closure, error) {
+	closure := &model.Closure{LocationID: in.LocationID, SpotNumbers: in.SpotNumbers, Reason: in.Reason}
+
+	start, err := utils.ParseDate(in.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	closure.StartDate = start
+
+	if in.EndDate != "" {
+		end, err := utils.ParseDate(in.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		closure.EndDate = end
+	}
+
+	return closure, nil
+}
+
+// ListClosuresHandler lists the closures, optionally only those of a location or overlapping the from/to range
+func (c *ClosureController) ListClosuresHandler(ctx *gin.Context) {
+	locationID, ok := locationQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var from, to time.Time
+	if value := ctx.Query("from"); value != "" {
+		parsed, err := utils.ParseDate(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date", "details": err.Error()})
+			return
+		}
+		from = parsed
+	}
+	if value := ctx.Query("to"); value != "" {
+		parsed, err := utils.ParseDate(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date", "details": err.Error()})
+			return
+		}
+		to = parsed
+	}
+
+	closures, err := c.ClosureService.ListClosures(ctx, locationID, from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": closures})
+}
+
+// CreateClosureHandler closes the lot, or some spot numbers, on a date or range of dates (admin only).
+// With cancel_existing, the reservations inside the closure are cancelled and their owners notified.
+func (c *ClosureController) CreateClosureHandler(ctx *gin.Context) {
+	var input closureInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	closure, err := input.toClosure()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkLocationAccess(ctx, c.ReservationService.Locations, closure.LocationID) {
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	closure.CreatedBy = adminID
+
+	if err := c.ClosureService.CreateClosure(ctx, closure); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "Closure created", "data": closure}
+	if input.CancelExisting {
+		cancelled, err := c.ReservationService.CancelReservationsInClosure(ctx, closure, adminID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Closure created but cancelling reservations failed: " + err.Error(), "data": closure, "cancelled": cancelled})
+			return
+		}
+		response["cancelled"] = cancelled
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// UpdateClosureHandler changes the dates, spot numbers or reason of a closure (admin only)
+func (c *ClosureController) UpdateClosureHandler(ctx *gin.Context) {
+	closureID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid closure ID"})
+		return
+	}
+
+	var input closureInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	closure, err := input.toClosure()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The admin must manage both the current and the new location of the closure
+	existing, err := c.ClosureService.GetClosure(ctx, closureID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkLocationAccess(ctx, c.ReservationService.Locations, existing.LocationID) ||
+		!checkLocationAccess(ctx, c.ReservationService.Locations, closure.LocationID) {
+		return
+	}
+
+	if err := c.ClosureService.UpdateClosure(ctx, closureID, closure); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Closure updated", "data": closure})
+}
+
+// DeleteClosureHandler reopens the dates of a closure (admin only)
+func (c *ClosureController) DeleteClosureHandler(ctx *gin.Context) {
+	closureID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid closure ID"})
+		return
+	}
+
+	existing, err := c.ClosureService.GetClosure(ctx, closureID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkLocationAccess(ctx, c.ReservationService.Locations, existing.LocationID) {
+		return
+	}
+
+	if err := c.ClosureService.DeleteClosure(ctx, closureID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Closure deleted"})
+}
@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gitlab.com/hooly2/back/model"
 	"gitlab.com/hooly2/back/services"
 	"gitlab.com/hooly2/back/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type FoodtruckController struct {
@@ -46,7 +50,11 @@ func (c *FoodtruckController) CreateFoodtruck(ctx *gin.Context) {
 	// Call the service to add the food truck
 	addedFoodtruck, err := c.FoodtruckServices.AddFoodtruck(&foodtruck)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create food truck"})
+		if strings.Contains(err.Error(), "requirements") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create food truck"})
+		}
 		return
 	}
 
@@ -119,6 +127,31 @@ func (c *FoodtruckController) GetFoodtruckByIDHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"data": foodtruck})
 }
 
+// GetFoodtruckAttendanceHandler retrieves the attendance history of a foodtruck (scoped by user ID unless admin).
+func (c *FoodtruckController) GetFoodtruckAttendanceHandler(ctx *gin.Context) {
+	foodtruckID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid foodtruck ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	if ctx.GetString("role") == "admin" {
+		userID = primitive.NilObjectID
+	}
+
+	attendance, err := c.FoodtruckServices.GetAttendance(ctx, foodtruckID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": attendance})
+}
+
 // UpdateFoodtruck Update a foodtruck
 func (c *FoodtruckController) UpdateFoodtruck(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -163,12 +196,19 @@ func (c *FoodtruckController) DeleteFoodtruck(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.FoodtruckServices.DeleteFoodtruck(ctx, foodtruckID, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	cascade, ok := cascadeQuery(ctx)
+	if !ok {
 		return
 	}
+	cascade.ActorID = userID
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Foodtruck deleted"})
+	report, err := c.FoodtruckServices.DeleteFoodtruck(ctx, foodtruckID, userID, cascade)
+	if err != nil {
+		deletionErrorResponse(ctx, report, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Foodtruck deleted", "report": report})
 }
 
 // AdminDeleteFoodtruck deletes any foodtruck (admin only).
@@ -187,11 +227,80 @@ func (c *FoodtruckController) AdminDeleteFoodtruck(ctx *gin.Context) {
 		return
 	}
 
-	err = c.FoodtruckServices.AdminDeleteFoodtruck(ctx, foodtruckID)
+	cascade, ok := cascadeQuery(ctx)
+	if !ok {
+		return
+	}
+	if cascade.ActorID, err = utils.GetUserIDFromContext(ctx); err != nil {
+		return
+	}
+
+	report, err := c.FoodtruckServices.AdminDeleteFoodtruck(ctx, foodtruckID, cascade)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete foodtruck"})
+		deletionErrorResponse(ctx, report, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Foodtruck deleted successfully", "report": report})
+}
+
+// SuspendFoodtruckHandler keeps a foodtruck from booking until a date, or lifts its suspension when until is null
+// (admin only).
+func (c *FoodtruckController) SuspendFoodtruckHandler(ctx *gin.Context) {
+	foodtruckID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid foodtruck ID"})
+		return
+	}
+
+	var input struct {
+		Until *time.Time `json:"until"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := c.FoodtruckServices.SuspendFoodtruck(ctx, foodtruckID, input.Until); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Foodtruck deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Foodtruck suspension updated"})
+}
+
+// cascadeQuery reads the cascade mode and the transfer_to user of a deletion from the query parameters, writing a 400
+// when transfer_to is malformed
+func cascadeQuery(c *gin.Context) (services.CascadeOptions, bool) {
+	cascade := services.CascadeOptions{Mode: c.Query("cascade")}
+
+	if value := c.Query("transfer_to"); value != "" {
+		transferTo, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer_to user ID"})
+			return cascade, false
+		}
+		cascade.TransferTo = transferTo
+	}
+	return cascade, true
+}
+
+// deletionErrorResponse writes the response of a failed deletion, listing the reservations blocking it
+func deletionErrorResponse(c *gin.Context, report *services.DeletionReport, err error) {
+	switch {
+	case errors.Is(err, services.ErrDeletionBlocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "report": report})
+	case errors.Is(err, services.ErrInvalidCascade):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransferForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrFoodtruckNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+type LocationController struct {
+	LocationService *services.LocationService
+}
+
+func NewLocationController(locationService *services.LocationService) *LocationController {
+	return &LocationController{LocationService: locationService}
+}
+
+// locationQuery reads the optional location_id query parameter, writing a 400 when it is malformed
+func locationQuery(c *gin.Context) (primitive.ObjectID, bool) {
+	value := c.Query("location_id")
+	if value == "" {
+		return primitive.NilObjectID, true
+	}
+
+	locationID, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return primitive.NilObjectID, false
+	}
+	return locationID, true
+}
+
+// checkLocationAccess writes a 403 and returns false when the current admin cannot manage the location.
+// A zero location stands for every location.
+func checkLocationAccess(c *gin.Context, locationService *services.LocationService, locationID primitive.ObjectID) bool {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return false
+	}
+
+	if err := locationService.CheckManager(c, adminID, locationID); err != nil {
+		if errors.Is(err, services.ErrLocationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// managedLocationScope returns the locations an admin listing is limited to: the location_id query parameter when
+// given, otherwise every location the admin manages, empty for all of them. It writes the error response and
+// returns false on failure.
+func managedLocationScope(c *gin.Context, locationService *services.LocationService) ([]primitive.ObjectID, bool) {
+	locationID, ok := locationQuery(c)
+	if !ok {
+		return nil, false
+	}
+	if !locationID.IsZero() {
+		if !checkLocationAccess(c, locationService, locationID) {
+			return nil, false
+		}
+		return []primitive.ObjectID{locationID}, true
+	}
+
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, false
+	}
+	locationIDs, err := locationService.ManagedLocations(c, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return locationIDs, true
+}
+
+// GetLocationsHandler lists every location
+func (lc *LocationController) GetLocationsHandler(c *gin.Context) {
+	locations, err := lc.LocationService.GetLocations(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": locations})
+}
+
+// GetLocationHandler retrieves a location by ID
+func (lc *LocationController) GetLocationHandler(c *gin.Context) {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	location, err := lc.LocationService.GetLocation(c, locationID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": location})
+}
+
+// CreateLocationHandler creates a location (unrestricted admins only)
+func (lc *LocationController) CreateLocationHandler(c *gin.Context) {
+	if !checkLocationAccess(c, lc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
+	var location model.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	if err := lc.LocationService.CreateLocation(c, &location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Location created successfully", "data": location})
+}
+
+// UpdateLocationHandler updates the details and opening days of a location
+func (lc *LocationController) UpdateLocationHandler(c *gin.Context) {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+	if !checkLocationAccess(c, lc.LocationService, locationID) {
+		return
+	}
+
+	var location model.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	if err := lc.LocationService.UpdateLocation(c, locationID, &location); err != nil {
+		if errors.Is(err, services.ErrLocationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location updated successfully", "data": location})
+}
+
+// UpdateSpotAttributesHandler replaces the description (size, power, water, shelter) of the spot numbers of a location
+func (lc *LocationController) UpdateSpotAttributesHandler(c *gin.Context) {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+	if !checkLocationAccess(c, lc.LocationService, locationID) {
+		return
+	}
+
+	var body struct {
+		Spots []model.SpotAttributes `json:"spots" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	if err := lc.LocationService.UpdateSpotAttributes(c, locationID, body.Spots); err != nil {
+		if errors.Is(err, services.ErrLocationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Spot attributes updated successfully", "data": body.Spots})
+}
+
+// DeleteLocationHandler deletes a location without parking spots (unrestricted admins only)
+func (lc *LocationController) DeleteLocationHandler(c *gin.Context) {
+	locationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+	if !checkLocationAccess(c, lc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
+	if err := lc.LocationService.DeleteLocation(c, locationID); err != nil {
+		if errors.Is(err, services.ErrLocationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted successfully"})
+}
+
+// SetManagedLocationsHandler restricts an admin to some locations, an empty list lifts the restriction
+// (unrestricted admins only)
+func (lc *LocationController) SetManagedLocationsHandler(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if !checkLocationAccess(c, lc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
+	var body struct {
+		LocationIDs []primitive.ObjectID `json:"location_ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	if err := lc.LocationService.SetManagedLocations(c, userID, body.LocationIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Managed locations updated successfully"})
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"strings"
+)
+
+type LotteryController struct {
+	LotteryService *services.LotteryService
+}
+
+func NewLotteryController(lotteryService *services.LotteryService) *LotteryController {
+	return &LotteryController{LotteryService: lotteryService}
+}
+
+// lotteryStatus maps lottery errors to HTTP statuses
+func lotteryStatus(err error) int {
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// GetRoundsHandler lists the lottery rounds, for one location when location_id is given
+func (c *LotteryController) GetRoundsHandler(ctx *gin.Context) {
+	locationID, ok := locationQuery(ctx)
+	if !ok {
+		return
+	}
+
+	rounds, err := c.LotteryService.GetRounds(ctx, locationID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": rounds})
+}
+
+// CreateRoundHandler opens a lottery round for a week of a location (admin only)
+func (c *LotteryController) CreateRoundHandler(ctx *gin.Context) {
+	var round model.LotteryRound
+	if err := ctx.ShouldBindJSON(&round); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if !checkLocationAccess(ctx, c.LotteryService.Reservations.Locations, round.LocationID) {
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	round.CreatedBy = adminID
+
+	if err := c.LotteryService.CreateRound(ctx, &round); err != nil {
+		ctx.JSON(lotteryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Lottery round opened", "data": round})
+}
+
+// GetRoundHandler retrieves a lottery round with its entries; users only see their own entries
+func (c *LotteryController) GetRoundHandler(ctx *gin.Context) {
+	roundID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid lottery round ID"})
+		return
+	}
+
+	round, err := c.LotteryService.GetRound(ctx, roundID)
+	if err != nil {
+		ctx.JSON(lotteryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var userID primitive.ObjectID
+	if ctx.GetString("role") != "admin" {
+		if userID, err = utils.GetUserIDFromContext(ctx); err != nil {
+			return
+		}
+	}
+
+	entries, err := c.LotteryService.GetEntries(ctx, roundID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": round, "entries": entries})
+}
+
+// SubmitEntryHandler records the wishes of a food truck of the current user for an open round
+func (c *LotteryController) SubmitEntryHandler(ctx *gin.Context) {
+	roundID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid lottery round ID"})
+		return
+	}
+
+	var entry model.LotteryEntry
+	if err := ctx.ShouldBindJSON(&entry); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if entry.FoodTruckID.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "food_truck_id is required"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	entry.UserID = userID
+
+	if err := c.LotteryService.SubmitEntry(ctx, roundID, &entry); err != nil {
+		ctx.JSON(lotteryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Lottery entry submitted", "data": entry})
+}
+
+// DrawHandler draws a round whose cutoff has passed without waiting for the sweeper (admin only)
+func (c *LotteryController) DrawHandler(ctx *gin.Context) {
+	roundID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid lottery round ID"})
+		return
+	}
+
+	round, err := c.LotteryService.GetRound(ctx, roundID)
+	if err != nil {
+		ctx.JSON(lotteryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !checkLocationAccess(ctx, c.LotteryService.Reservations.Locations, round.LocationID) {
+		return
+	}
+
+	round, err = c.LotteryService.Draw(ctx, roundID)
+	if err != nil {
+		ctx.JSON(lotteryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := c.LotteryService.GetEntries(ctx, roundID, primitive.NilObjectID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Lottery round drawn", "data": round, "entries": entries})
+}
+
+// VerifyDrawHandler replays the draw of a round from its stored seed (admin only)
+func (c *LotteryController) VerifyDrawHandler(ctx *gin.Context) {
+	roundID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid lottery round ID"})
+		return
+	}
+
+	verified, err := c.LotteryService.VerifyDraw(ctx, roundID)
+	if err != nil {
+		ctx.JSON(lotteryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"verified": verified})
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+type NotificationController struct {
+	NotificationService *services.NotificationService
+}
+
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{NotificationService: notificationService}
+}
+
+// GetUserNotificationsHandler retrieves the notifications of the logged-in user
+func (c *NotificationController) GetUserNotificationsHandler(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	notifications, err := c.NotificationService.GetUserNotifications(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notifications"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": notifications})
+}
+
+// MarkNotificationReadHandler marks one of the logged-in user's notifications as read
+func (c *NotificationController) MarkNotificationReadHandler(ctx *gin.Context) {
+	notificationID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := c.NotificationService.MarkNotificationRead(ctx, notificationID, userID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
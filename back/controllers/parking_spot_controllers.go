@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gitlab.com/hooly2/back/model"
 	"gitlab.com/hooly2/back/services"
 	"gitlab.com/hooly2/back/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type ParkingSpotController struct {
@@ -18,12 +21,36 @@ func NewParkingSpotController(parkingSpotController *services.ParkingSpotService
 	return &ParkingSpotController{ParkingSpotServices: parkingSpotController}
 }
 
-// ListAllParkingSpots handles GET requests to list all parking spots or filter by day
+// ListAllParkingSpots handles GET requests to list all parking spots, filtered by location, day or a concrete date.
+// With a date, slot_id narrows the occupancy to one slot of the day.
 func (ctrl *ParkingSpotController) ListAllParkingSpots(c *gin.Context) {
 	dayOfWeek := c.Query("day_of_week")
+	locationID, ok := locationQuery(c)
+	if !ok {
+		return
+	}
+	foodTruckID, ok := foodTruckQuery(c)
+	if !ok {
+		return
+	}
 
-	spots, err := ctrl.ParkingSpotServices.ListAllParkingSpots(dayOfWeek, c.Request.Context())
+	var date time.Time
+	if value := c.Query("date"); value != "" {
+		parsed, err := utils.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		date = parsed
+	}
+
+	slotID := c.Query("slot_id")
+	spots, err := ctrl.ParkingSpotServices.ListAllParkingSpots(locationID, dayOfWeek, date, slotID, foodTruckID, c.Request.Context())
 	if err != nil {
+		if slotID != "" && strings.Contains(err.Error(), "slot") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parking spots", "details": err.Error()})
 		return
 	}
@@ -36,6 +63,43 @@ func (ctrl *ParkingSpotController) ListAllParkingSpots(c *gin.Context) {
 	c.JSON(http.StatusOK, spots)
 }
 
+// GetAvailabilityHandler handles GET requests listing free and taken spot numbers per date between from and to,
+// for one location when location_id is given and only spots suiting the food truck when food_truck_id is given
+func (ctrl *ParkingSpotController) GetAvailabilityHandler(c *gin.Context) {
+	locationID, ok := locationQuery(c)
+	if !ok {
+		return
+	}
+	foodTruckID, ok := foodTruckQuery(c)
+	if !ok {
+		return
+	}
+
+	from, err := utils.ParseDate(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date", "details": err.Error()})
+		return
+	}
+
+	// A missing 'to' means a single day
+	to := from
+	if value := c.Query("to"); value != "" {
+		to, err = utils.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date", "details": err.Error()})
+			return
+		}
+	}
+
+	availability, err := ctrl.ParkingSpotServices.GetAvailability(c.Request.Context(), locationID, from, to, foodTruckID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to compute availability", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": availability})
+}
+
 // CreateParkingSpotHandler handles POST requests to create a new parking spot
 func (ctrl *ParkingSpotController) CreateParkingSpotHandler(c *gin.Context) {
 	userRole, exists := c.Get("role")
@@ -57,10 +121,19 @@ func (ctrl *ParkingSpotController) CreateParkingSpotHandler(c *gin.Context) {
 		return
 	}
 
+	// Restricted admins only create parking spots in their locations
+	if !checkLocationAccess(c, ctrl.ParkingSpotServices.Locations, parkingSpot.LocationID) {
+		return
+	}
+
 	// Call the service to create the parking spot
-	createdSpot, err := ctrl.ParkingSpotServices.CreateParkingSpot(parkingSpot.Day, c.Request.Context())
+	createdSpot, err := ctrl.ParkingSpotServices.CreateParkingSpot(parkingSpot.LocationID, parkingSpot.Day, parkingSpot.SpotNumbers, parkingSpot.MaxCapacity, parkingSpot.Slots, c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -99,3 +172,73 @@ func (ctrl *ParkingSpotController) UpdateReservationStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Reservation status updated successfully"})
 }
+
+// UpdateParkingSpotLayoutHandler handles PUT requests replacing the spot numbers, capacity and slots of a weekday.
+// Future reservations on removed spot numbers must be relocated through the relocations map.
+func (ctrl *ParkingSpotController) UpdateParkingSpotLayoutHandler(c *gin.Context) {
+	if c.GetString("role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	spotID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spot ID"})
+		return
+	}
+
+	spot, err := ctrl.ParkingSpotServices.GetParkingSpot(c.Request.Context(), spotID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkLocationAccess(c, ctrl.ParkingSpotServices.Locations, spot.LocationID) {
+		return
+	}
+
+	var body struct {
+		SpotNumbers []int            `json:"spot_numbers" binding:"required"`
+		MaxCapacity int              `json:"max_capacity"`
+		Slots       []model.TimeSlot `json:"slots"`       // Empty to book the day as a whole
+		Relocations map[int]int      `json:"relocations"` // Removed spot number -> new spot number
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	updated, relocated, err := ctrl.ParkingSpotServices.UpdateParkingSpotLayout(c.Request.Context(), spotID, body.SpotNumbers, body.MaxCapacity, body.Slots, body.Relocations)
+	if err != nil {
+		var conflictErr *services.LayoutConflictError
+		switch {
+		case errors.As(err, &conflictErr):
+			c.JSON(http.StatusConflict, gin.H{"error": "Layout change conflicts with future reservations", "details": conflictErr.Reasons, "reservations": conflictErr.Reservations})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Parking spot layout updated successfully",
+		"parking_spot": updated,
+		"relocated":    relocated,
+	})
+}
+
+// foodTruckQuery reads the optional food_truck_id query parameter, writing a 400 when it is malformed
+func foodTruckQuery(c *gin.Context) (primitive.ObjectID, bool) {
+	value := c.Query("food_truck_id")
+	if value == "" {
+		return primitive.NilObjectID, true
+	}
+
+	foodTruckID, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid food truck ID"})
+		return primitive.NilObjectID, false
+	}
+	return foodTruckID, true
+}
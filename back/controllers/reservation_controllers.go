@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gitlab.com/hooly2/back/model"
 	"gitlab.com/hooly2/back/services"
 	"gitlab.com/hooly2/back/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -28,7 +31,12 @@ func (c *ReservationController) GetAllReservationsHandler(ctx *gin.Context) {
 		return
 	}
 
-	reservations, err := c.ReservationService.GetAllReservations(ctx)
+	locationIDs, ok := managedLocationScope(ctx, c.ReservationService.Locations)
+	if !ok {
+		return
+	}
+
+	reservations, err := c.ReservationService.GetAllReservations(ctx, locationIDs)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -122,6 +130,7 @@ func (c *ReservationController) CreateReservationHandler(ctx *gin.Context) {
 		return
 	}
 	reservation.UserID = objectID
+	reservation.SeriesID = primitive.NilObjectID
 
 	// Validate that SpotID is a valid ObjectID
 	if !reservation.SpotID.IsZero() {
@@ -135,11 +144,24 @@ func (c *ReservationController) CreateReservationHandler(ctx *gin.Context) {
 	err = c.ReservationService.CreateReservation(ctx, &reservation)
 	if err != nil {
 		// Check for specific error messages to send a 400 Bad Request
-		if strings.Contains(err.Error(), "spot is not available") {
+		var violation *services.PolicyViolation
+		var incompatibleErr *services.IncompatibleSpotError
+		if errors.As(err, &violation) {
+			ctx.JSON(http.StatusBadRequest, policyViolationResponse(violation))
+		} else if errors.As(err, &incompatibleErr) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "reasons": incompatibleErr.Reasons})
+		} else if errors.Is(err, services.ErrSpotConflict) || errors.Is(err, services.ErrSpotFull) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else if errors.Is(err, services.ErrLotteryWindow) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else if errors.Is(err, services.ErrFoodtruckForbidden) || errors.Is(err, services.ErrFoodtruckSuspended) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if errors.Is(err, services.ErrFoodtruckNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else if strings.Contains(err.Error(), "spot is not available") {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Spot is not available"})
-		} else if strings.Contains(err.Error(), "already has a reservation") ||
-			strings.Contains(err.Error(), "no available spots") ||
-			strings.Contains(err.Error(), "past date or today") {
+		} else if strings.Contains(err.Error(), "past date") ||
+			strings.Contains(err.Error(), "booking is suspended") {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reservation"})
@@ -154,50 +176,211 @@ func (c *ReservationController) CreateReservationHandler(ctx *gin.Context) {
 	}
 
 	// Respond with the created reservation details
+	message := "Reservation created successfully"
+	if reservation.Status == model.StatusPending {
+		message = "Reservation created, waiting for approval"
+	}
 	ctx.JSON(http.StatusCreated, gin.H{
-		"message": "Reservation created successfully",
+		"message": message,
 		"reservation": gin.H{
-			"id":            reservation.ID.Hex(),
-			"spot_id":       reservation.SpotID.Hex(),
-			"food_truck_id": reservation.FoodTruckID.Hex(),
-			"user_id":       reservation.UserID.Hex(),
-			"date":          reservation.Date,
-			"created_at":    reservation.CreatedAt,
+			"id":                reservation.ID.Hex(),
+			"spot_id":           reservation.SpotID.Hex(),
+			"food_truck_id":     reservation.FoodTruckID.Hex(),
+			"food_truck_name":   reservation.FoodTruckName,
+			"user_id":           reservation.UserID.Hex(),
+			"spot_number":       reservation.SpotNumber,
+			"slot_id":           reservation.SlotID,
+			"date":              reservation.Date,
+			"created_at":        reservation.CreatedAt,
+			"status":            reservation.Status,
+			"approval_deadline": reservation.ApprovalDeadline,
 		},
 	})
 }
 
-// UpdateReservationHandler updates an existing reservation.
+// ValidateReservationHandler runs every check of a reservation without booking it, reporting all violations along
+// with suggested dates and spot numbers.
+func (c *ReservationController) ValidateReservationHandler(ctx *gin.Context) {
+	var reservation model.Reservation
+	if err := ctx.ShouldBindJSON(&reservation); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	reservation.UserID = userID
+	reservation.SeriesID = primitive.NilObjectID
+
+	validation, err := c.ReservationService.ValidateReservation(ctx, &reservation)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate reservation", "details": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": validation})
+}
+
+// CreateHoldHandler keeps a spot number for the current user for a few minutes while they finish booking. The
+// hold is turned into a reservation by creating the same reservation before it expires.
+func (c *ReservationController) CreateHoldHandler(ctx *gin.Context) {
+	var hold model.ReservationHold
+	if err := ctx.ShouldBindJSON(&hold); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	hold.UserID = userID
+
+	if err := c.ReservationService.CreateHold(ctx, &hold); err != nil {
+		var violation *services.PolicyViolation
+		switch {
+		case errors.As(err, &violation):
+			ctx.JSON(http.StatusBadRequest, policyViolationResponse(violation))
+		case errors.Is(err, services.ErrSpotConflict) || errors.Is(err, services.ErrSpotFull):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrFoodtruckForbidden) || errors.Is(err, services.ErrFoodtruckSuspended):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "failed to"):
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hold spot"})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Spot held", "data": hold})
+}
+
+// GetUserHoldsHandler lists the holds of the current user that have not expired.
+func (c *ReservationController) GetUserHoldsHandler(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	holds, err := c.ReservationService.GetUserHolds(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holds"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": holds})
+}
+
+// ReleaseHoldHandler gives back a spot number held by the current user.
+func (c *ReservationController) ReleaseHoldHandler(ctx *gin.Context) {
+	holdID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid hold ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := c.ReservationService.ReleaseHold(ctx, holdID, userID); err != nil {
+		if errors.Is(err, services.ErrHoldNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Hold released"})
+}
+
+// UpdateReservationHandler moves a reservation of the current user to another date, slot or spot number. Only
+// those fields are accepted; admins may update any reservation.
 func (c *ReservationController) UpdateReservationHandler(ctx *gin.Context) {
 	id := ctx.Param("id")
 	reservationID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
 		return
 	}
 
-	var updateData bson.M
-	if err := ctx.ShouldBindJSON(&updateData); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var update model.ReservationUpdate
+	decoder := json.NewDecoder(ctx.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
 
 	// Get user_id from context (set during authentication)
 	userID, err := utils.GetUserIDFromContext(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
 		return
 	}
+	if ctx.GetString("role") == "admin" {
+		userID = primitive.NilObjectID
+	}
 
-	if err := c.ReservationService.UpdateReservation(ctx, reservationID, updateData, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	reservation, err := c.ReservationService.UpdateReservation(ctx, reservationID, &update, userID)
+	if err != nil {
+		var violation *services.PolicyViolation
+		var incompatibleErr *services.IncompatibleSpotError
+		switch {
+		case errors.As(err, &violation):
+			ctx.JSON(http.StatusBadRequest, policyViolationResponse(violation))
+		case errors.As(err, &incompatibleErr):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "reasons": incompatibleErr.Reasons})
+		case errors.Is(err, services.ErrSpotConflict) || errors.Is(err, services.ErrSpotFull):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "failed to"):
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "reservation updated"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation updated", "data": reservation})
 }
 
-// DeleteReservationHandler deletes a reservation by ID.
+// TransferReservationHandler gives a reservation to another food truck of the same owner.
+func (c *ReservationController) TransferReservationHandler(ctx *gin.Context) {
+	reservationID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	var input struct {
+		FoodTruckID primitive.ObjectID `json:"food_truck_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	reservation, err := c.ReservationService.TransferReservation(ctx, reservationID, input.FoodTruckID, userID)
+	if err != nil {
+		exchangeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation transferred", "data": reservation})
+}
+
+// DeleteReservationHandler cancels a reservation of the current user by ID.
 func (c *ReservationController) DeleteReservationHandler(ctx *gin.Context) {
 	id := ctx.Param("id")
 	reservationID, err := primitive.ObjectIDFromHex(id)
@@ -212,15 +395,16 @@ func (c *ReservationController) DeleteReservationHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.ReservationService.DeleteReservation(ctx, reservationID, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := c.ReservationService.DeleteReservation(ctx, reservationID, userID)
+	if err != nil {
+		ctx.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "reservation deleted"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation cancelled", "policy": result})
 }
 
-// AdminDeleteReservationHandler deletes any reservation (admin only).
+// AdminDeleteReservationHandler cancels any reservation (admin only).
 func (c *ReservationController) AdminDeleteReservationHandler(ctx *gin.Context) {
 	userRole, _ := ctx.Get("role")
 	if userRole != "admin" {
@@ -236,13 +420,375 @@ func (c *ReservationController) AdminDeleteReservationHandler(ctx *gin.Context)
 		return
 	}
 
-	// Call AdminDeleteReservation to handle the deletion and reserved count update
-	err = c.ReservationService.AdminDeleteReservation(ctx, reservationID)
+	if _, ok := c.managedReservation(ctx, reservationID); !ok {
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call AdminDeleteReservation to cancel the reservation and release its spot number
+	err = c.ReservationService.AdminDeleteReservation(ctx, reservationID, adminID)
+	if err != nil {
+		ctx.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Respond to the client with a success message
-	ctx.JSON(http.StatusOK, gin.H{"message": "reservation deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation cancelled successfully"})
+}
+
+// ChangeReservationStatusHandler moves a reservation to a new status. Users may only cancel their own
+// reservations, admins may apply any transition allowed by the status table.
+func (c *ReservationController) ChangeReservationStatusHandler(ctx *gin.Context) {
+	reservationID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID"})
+		return
+	}
+
+	var body struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	// Regular users can only cancel their own reservations, under the cancellation policy
+	if ctx.GetString("role") != "admin" {
+		if body.Status != model.StatusCancelled {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+
+		result, err := c.ReservationService.CancelReservation(ctx, reservationID, actorID, body.Reason)
+		if err != nil {
+			ctx.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "reservation cancelled", "policy": result})
+		return
+	}
+
+	reservation, ok := c.managedReservation(ctx, reservationID)
+	if !ok {
+		return
+	}
+
+	// Pending reservations are only reviewed through the approval endpoints, which require a reason to reject and
+	// tell the user
+	if reservation.Status == model.StatusPending {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "pending reservations must be approved or rejected from the approval queue"})
+		return
+	}
+
+	reservation, err = c.ReservationService.ChangeReservationStatus(ctx, reservationID, primitive.NilObjectID, actorID, body.Status, body.Reason)
+	if err != nil {
+		ctx.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation status updated", "data": reservation})
+}
+
+// GetPendingApprovalsHandler lists the reservations waiting for approval with their food trucks, at one location
+// when location_id is given and otherwise at every location the admin manages (admin only).
+func (c *ReservationController) GetPendingApprovalsHandler(ctx *gin.Context) {
+	locationIDs, ok := managedLocationScope(ctx, c.ReservationService.Locations)
+	if !ok {
+		return
+	}
+
+	pending, err := c.ReservationService.GetPendingApprovals(ctx, locationIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": pending})
+}
+
+// ApproveReservationHandler confirms a pending reservation (admin only).
+func (c *ReservationController) ApproveReservationHandler(ctx *gin.Context) {
+	c.reviewReservation(ctx, c.ReservationService.ApproveReservation, "reservation approved")
+}
+
+// RejectReservationHandler refuses a pending reservation with a reason, releasing its spot (admin only).
+func (c *ReservationController) RejectReservationHandler(ctx *gin.Context) {
+	c.reviewReservation(ctx, c.ReservationService.RejectReservation, "reservation rejected")
+}
+
+func (c *ReservationController) reviewReservation(ctx *gin.Context, review func(context.Context, primitive.ObjectID, primitive.ObjectID, string) (*model.Reservation, error), message string) {
+	reservationID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID"})
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := c.managedReservation(ctx, reservationID); !ok {
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	reservation, err := review(ctx, reservationID, adminID, body.Reason)
+	if err != nil {
+		code := statusErrorCode(err)
+		if strings.Contains(err.Error(), "reason is required") {
+			code = http.StatusBadRequest
+		}
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message, "data": reservation})
+}
+
+// GetCheckinQRCodeHandler returns the check-in QR code of a reservation of the current user as a PNG image.
+func (c *ReservationController) GetCheckinQRCodeHandler(ctx *gin.Context) {
+	reservationID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	if ctx.GetString("role") == "admin" {
+		userID = primitive.NilObjectID
+	}
+
+	image, err := c.ReservationService.GetCheckinQRCode(ctx, reservationID, userID)
+	if err != nil {
+		ctx.JSON(statusErrorCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/png", image)
+}
+
+// CheckInHandler validates a scanned check-in token and marks the truck as arrived (admin only).
+func (c *ReservationController) CheckInHandler(ctx *gin.Context) {
+	c.handleScan(ctx, c.ReservationService.CheckIn, "truck checked in")
+}
+
+// CheckOutHandler validates a scanned check-in token and marks the truck as gone (admin only).
+func (c *ReservationController) CheckOutHandler(ctx *gin.Context) {
+	c.handleScan(ctx, c.ReservationService.CheckOut, "truck checked out")
+}
+
+// handleScan binds a scanned token and applies the given check-in or check-out action
+func (c *ReservationController) handleScan(ctx *gin.Context, action func(context.Context, string, int, primitive.ObjectID) (*model.Reservation, error), message string) {
+	var body struct {
+		Token      string `json:"token" binding:"required"`
+		SpotNumber int    `json:"spot_number"` // Spot where the code was scanned, optional
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Admins only scan trucks at the locations they manage
+	content, err := utils.ParseCheckinToken(body.Token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reservationID, err := primitive.ObjectIDFromHex(content.ReservationID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "malformed check-in token"})
+		return
+	}
+	if _, ok := c.managedReservation(ctx, reservationID); !ok {
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	reservation, err := action(ctx, body.Token, body.SpotNumber, adminID)
+	if err != nil {
+		// Anything but a conflict or a missing reservation means the scanned token was rejected
+		code := statusErrorCode(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message, "data": reservation})
+}
+
+// managedReservation loads a reservation for the current admin, writing a 404 when it does not exist and a 403 when
+// it is at a location the admin does not manage
+func (c *ReservationController) managedReservation(ctx *gin.Context, reservationID primitive.ObjectID) (*model.Reservation, bool) {
+	reservation, err := c.ReservationService.GetReservationByID(ctx, reservationID, primitive.NilObjectID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+		return nil, false
+	}
+	if !checkLocationAccess(ctx, c.ReservationService.Locations, reservation.LocationID) {
+		return nil, false
+	}
+	return reservation, true
+}
+
+// statusErrorCode maps status change errors to an HTTP status code
+func statusErrorCode(err error) int {
+	var transitionErr *services.InvalidTransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid reservation status"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// policyViolationResponse describes the booking rule a reservation breaks along with the details of the rule
+func policyViolationResponse(violation *services.PolicyViolation) gin.H {
+	return gin.H{
+		"error":   violation.Message,
+		"rule":    violation.Rule,
+		"details": violation.Details,
+	}
+}
+
+// CreateReservationSeriesHandler books a spot number on a fixed weekday for a number of weeks or until an end date.
+func (c *ReservationController) CreateReservationSeriesHandler(ctx *gin.Context) {
+	var series model.ReservationSeries
+	if err := ctx.ShouldBindJSON(&series); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	series.UserID = userID
+
+	occurrences, err := c.ReservationService.CreateReservationSeries(ctx, &series)
+	if errors.Is(err, services.ErrSeriesNotBooked) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "occurrences": occurrences})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":     "Reservation series created",
+		"series":      series,
+		"occurrences": occurrences,
+	})
+}
+
+// GetReservationSeriesHandler retrieves a series of the current user with its reservations.
+func (c *ReservationController) GetReservationSeriesHandler(ctx *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	series, reservations, err := c.ReservationService.GetReservationSeries(ctx, seriesID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"series": series, "reservations": reservations}})
+}
+
+// CancelReservationSeriesHandler cancels every remaining occurrence of a series.
+func (c *ReservationController) CancelReservationSeriesHandler(ctx *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	cancelled, err := c.ReservationService.CancelReservationSeries(ctx, seriesID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "cancelled": cancelled})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation series cancelled", "cancelled": cancelled})
+}
+
+// SkipReservationSeriesDateHandler cancels one occurrence of a series.
+func (c *ReservationController) SkipReservationSeriesDateHandler(ctx *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	var body struct {
+		Date string `json:"date" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := utils.ParseDate(body.Date)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := c.ReservationService.SkipReservationSeriesDate(ctx, seriesID, userID, date); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "occurrence skipped"})
 }
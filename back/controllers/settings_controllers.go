@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+type SettingsController struct {
+	SettingsService *services.SettingsService
+	LocationService *services.LocationService
+}
+
+func NewSettingsController(settingsService *services.SettingsService, locationService *services.LocationService) *SettingsController {
+	return &SettingsController{SettingsService: settingsService, LocationService: locationService}
+}
+
+// GetCancellationPolicyHandler returns the current cancellation policy
+func (sc *SettingsController) GetCancellationPolicyHandler(c *gin.Context) {
+	policy, err := sc.SettingsService.GetCancellationPolicy(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// UpdateCancellationPolicyHandler replaces the cancellation policy
+func (sc *SettingsController) UpdateCancellationPolicyHandler(c *gin.Context) {
+	// The policy applies to every location
+	if !checkLocationAccess(c, sc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
+	var policy model.CancellationPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return
+	}
+
+	if err := sc.SettingsService.UpdateCancellationPolicy(c, &policy, adminID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cancellation policy updated", "data": policy})
+}
+
+// GetBookingPolicyHandler returns the current booking policy
+func (sc *SettingsController) GetBookingPolicyHandler(c *gin.Context) {
+	policy, err := sc.SettingsService.GetBookingPolicy(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// UpdateBookingPolicyHandler replaces the booking policy
+func (sc *SettingsController) UpdateBookingPolicyHandler(c *gin.Context) {
+	// The policy applies to every location
+	if !checkLocationAccess(c, sc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
+	var policy model.BookingPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return
+	}
+
+	if err := sc.SettingsService.UpdateBookingPolicy(c, &policy, adminID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking policy updated", "data": policy})
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"strings"
+)
+
+type SwapController struct {
+	SwapService *services.SwapService
+}
+
+func NewSwapController(swapService *services.SwapService) *SwapController {
+	return &SwapController{SwapService: swapService}
+}
+
+// ProposeSwapHandler offers a reservation of the current user's food truck in exchange for another food truck's.
+func (c *SwapController) ProposeSwapHandler(ctx *gin.Context) {
+	var swap model.ReservationSwap
+	if err := ctx.ShouldBindJSON(&swap); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if swap.ReservationID.IsZero() || swap.TargetReservationID.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "reservation_id and target_reservation_id are required"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	swap.ProposerID = userID
+
+	if err := c.SwapService.ProposeSwap(ctx, &swap); err != nil {
+		exchangeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Swap proposed", "data": swap})
+}
+
+// GetUserSwapsHandler lists the swaps proposed by or to the current user.
+func (c *SwapController) GetUserSwapsHandler(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	swaps, err := c.SwapService.GetUserSwaps(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch swaps"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": swaps})
+}
+
+// AcceptSwapHandler exchanges the reservations of a swap proposed to the current user.
+func (c *SwapController) AcceptSwapHandler(ctx *gin.Context) {
+	c.respond(ctx, true)
+}
+
+// DeclineSwapHandler refuses a swap proposed to the current user.
+func (c *SwapController) DeclineSwapHandler(ctx *gin.Context) {
+	c.respond(ctx, false)
+}
+
+func (c *SwapController) respond(ctx *gin.Context, accept bool) {
+	swapID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid swap ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	swap, err := c.SwapService.RespondToSwap(ctx, swapID, userID, accept)
+	if err != nil {
+		exchangeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Swap " + swap.Status, "data": swap})
+}
+
+// CancelSwapHandler withdraws a swap the current user proposed.
+func (c *SwapController) CancelSwapHandler(ctx *gin.Context) {
+	swapID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid swap ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := c.SwapService.CancelSwap(ctx, swapID, userID); err != nil {
+		exchangeErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Swap cancelled"})
+}
+
+// exchangeErrorResponse maps the errors of swaps and transfers to an HTTP response
+func exchangeErrorResponse(ctx *gin.Context, err error) {
+	var violation *services.PolicyViolation
+	var incompatibleErr *services.IncompatibleSpotError
+	switch {
+	case errors.As(err, &violation):
+		ctx.JSON(http.StatusBadRequest, policyViolationResponse(violation))
+	case errors.As(err, &incompatibleErr):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "reasons": incompatibleErr.Reasons})
+	case errors.Is(err, services.ErrFoodtruckForbidden) || errors.Is(err, services.ErrFoodtruckSuspended):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReservationChanged), strings.Contains(err.Error(), "already"):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "failed to"):
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -12,11 +12,12 @@ import (
 )
 
 type UserController struct {
-	UserServices *services.UserService
+	UserServices    *services.UserService
+	LocationService *services.LocationService
 }
 
-func NewUserController(userController *services.UserService) *UserController {
-	return &UserController{UserServices: userController}
+func NewUserController(userController *services.UserService, locationService *services.LocationService) *UserController {
+	return &UserController{UserServices: userController, LocationService: locationService}
 }
 
 // CreateUser handles creating a new user
@@ -30,6 +31,11 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Admins restricted to some locations cannot create accounts, new admins managing every location
+	if !checkLocationAccess(c, uc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
 	// Bind the request body to a struct
 	var userInput struct {
 		FirstName string `json:"first_name" binding:"required"`
@@ -169,17 +175,28 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	// Only admins managing every location may delete other users
+	if currentUserID != userIDPrimitive && !checkLocationAccess(c, uc.LocationService, primitive.NilObjectID) {
+		return
+	}
+
+	cascade, ok := cascadeQuery(c)
+	if !ok {
+		return
+	}
+	cascade.ActorID = currentUserID
+
 	// Call UserService to delete the user from the database
-	err := uc.UserServices.DeleteUser(userID)
+	report, err := uc.UserServices.DeleteUser(c, userID, cascade)
 	if err != nil {
-		if errors.Is(mongo.ErrNoDocuments, err) {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			deletionErrorResponse(c, report, err)
 		}
 		return
 	}
 
 	// Respond with a success message
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully", "report": report})
 }
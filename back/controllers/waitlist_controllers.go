@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"time"
+)
+
+type WaitlistController struct {
+	WaitlistService *services.WaitlistService
+}
+
+func NewWaitlistController(waitlistService *services.WaitlistService) *WaitlistController {
+	return &WaitlistController{WaitlistService: waitlistService}
+}
+
+// JoinWaitlistHandler queues a food truck of the current user for a full date.
+func (c *WaitlistController) JoinWaitlistHandler(ctx *gin.Context) {
+	var entry model.WaitlistEntry
+	if err := ctx.ShouldBindJSON(&entry); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if entry.FoodTruckID.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "food_truck_id is required"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+	entry.UserID = userID
+
+	if err := c.WaitlistService.JoinWaitlist(ctx, &entry); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Joined waitlist successfully", "data": entry})
+}
+
+// GetUserWaitlistHandler retrieves the waitlist entries of the logged-in user.
+func (c *WaitlistController) GetUserWaitlistHandler(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	entries, err := c.WaitlistService.GetUserWaitlist(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch waitlist"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// GetWaitlistHandler retrieves the whole waitlist, optionally for one date (admin only).
+func (c *WaitlistController) GetWaitlistHandler(ctx *gin.Context) {
+	if ctx.GetString("role") != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+
+	var date time.Time
+	if value := ctx.Query("date"); value != "" {
+		parsed, err := utils.ParseDate(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		date = parsed
+	}
+
+	locationIDs, ok := managedLocationScope(ctx, c.WaitlistService.Reservations.Locations)
+	if !ok {
+		return
+	}
+
+	entries, err := c.WaitlistService.GetWaitlist(ctx, date, locationIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// LeaveWaitlistHandler removes one of the current user's entries from the waitlist.
+func (c *WaitlistController) LeaveWaitlistHandler(ctx *gin.Context) {
+	entryID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid waitlist entry ID"})
+		return
+	}
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := c.WaitlistService.LeaveWaitlist(ctx, entryID, userID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "left waitlist"})
+}
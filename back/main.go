@@ -1,15 +1,70 @@
 package main
 
 import (
+	"context"
 	"gitlab.com/hooly2/back/db"
 	"gitlab.com/hooly2/back/routes"
+	"gitlab.com/hooly2/back/services"
+	"gitlab.com/hooly2/back/utils"
 	"log"
+	"time"
 )
 
 func main() {
 	// Connect to MongoDB
 	db.Connect()
 
+	// Check-in codes cannot be signed without their own secret
+	if _, err := utils.CheckinSecret(); err != nil {
+		log.Fatal("Error loading check-in secret: ", err)
+	}
+
+	// Attach existing parking spots and reservations to the default location
+	if err := services.NewLocationService().MigrateDefaultLocation(context.Background()); err != nil {
+		log.Fatal("Error migrating to locations: ", err)
+	}
+
+	// Create the indexes guarding concurrent bookings
+	if err := services.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal("Error creating indexes: ", err)
+	}
+
+	// Build the date-scoped spot occupancy from existing reservations on first start
+	if err := services.NewOccupancyService().BackfillOccupancy(context.Background()); err != nil {
+		log.Fatal("Error backfilling spot occupancy: ", err)
+	}
+
+	// Record the food truck name on reservations made before it was stored
+	if err := services.NewReservationService().BackfillFoodtruckNames(context.Background()); err != nil {
+		log.Fatal("Error backfilling food truck names: ", err)
+	}
+
+	// Make closures of every location saved with a zero location ID apply again
+	if err := services.NewClosureService().BackfillLocations(context.Background()); err != nil {
+		log.Fatal("Error backfilling closure locations: ", err)
+	}
+
+	// Store the spot number of waitlist entries for any spot saved without one
+	if err := services.NewWaitlistService(services.NewReservationService()).BackfillSpotNumbers(context.Background()); err != nil {
+		log.Fatal("Error backfilling waitlist spot numbers: ", err)
+	}
+
+	// Mark reservations of past days without a check-in as no-shows
+	go services.NewReservationService().RunNoShowSweeper(context.Background(), time.Hour)
+
+	// Give back the spot numbers of expired holds, offering them to the waitlist first
+	holdSweeper := services.NewReservationService()
+	holdSweeper.OnRelease = services.NewWaitlistService(holdSweeper).PromoteNext
+	go holdSweeper.RunHoldSweeper(context.Background(), 30*time.Second)
+
+	// Expire the reservations left pending past their approval deadline, offering their spots to the waitlist
+	approvalSweeper := services.NewReservationService()
+	approvalSweeper.OnRelease = services.NewWaitlistService(approvalSweeper).PromoteNext
+	go approvalSweeper.RunApprovalSweeper(context.Background(), 5*time.Minute)
+
+	// Draw the lottery rounds whose cutoff has passed
+	go services.NewLotteryService(services.NewReservationService()).RunLotterySweeper(context.Background(), 5*time.Minute)
+
 	// Set up routes
 	r := routes.SetupRouter()
 
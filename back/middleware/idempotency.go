@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// IdempotencyHeader is the request header carrying the key clients reuse when retrying a request
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys accepted from clients
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyRecord is the first response given to a request with an idempotency key
+type IdempotencyRecord struct {
+	RequestHash string // Hash of the method, path and body of the first request
+	Completed   bool   // False while the first request is still being handled
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyStore keeps the responses of requests made with an idempotency key, per user and key, until they
+// expire
+type IdempotencyStore interface {
+	// Begin claims a key for a request. It returns nil when the key is new, or the record of the request that
+	// claimed it first.
+	Begin(ctx context.Context, userID, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error)
+	// Complete stores the response given to the request that claimed a key
+	Complete(ctx context.Context, userID, key string, record *IdempotencyRecord) error
+	// Abandon frees a key whose request failed so that it can be retried
+	Abandon(ctx context.Context, userID, key string) error
+}
+
+// responseRecorder copies the response body written by the handlers
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// hashRequest identifies a request by its method, path and body
+func hashRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IdempotencyMiddleware replays the first response of a request when it is retried with the same Idempotency-Key
+// header by the same user. Reusing a key for a different request is rejected, and so is a retry arriving while the
+// first request is still handled. Requests without the header are handled as usual; server errors are not kept so
+// that they can be retried.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := hashRequest(c.Request.Method, c.Request.URL.Path, body)
+
+		userID := c.GetString("userId")
+		existing, err := store.Begin(c, userID, key, requestHash, ttl)
+		if err != nil {
+			log.Println("Error claiming idempotency key:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		}
+
+		switch {
+		case existing == nil:
+		case existing.RequestHash != requestHash:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			c.Abort()
+			return
+		case !existing.Completed:
+			c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+			c.Abort()
+			return
+		default:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.Status, existing.ContentType, existing.Body)
+			c.Abort()
+			return
+		}
+
+		// Use a fresh context: the request one may be cancelled once the response is written
+		ctx := context.Background()
+
+		// A panicking handler must not leave the key in progress until it expires
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				_ = store.Abandon(ctx, userID, key)
+				panic(recovered)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := store.Abandon(ctx, userID, key); err != nil {
+				log.Println("Error freeing idempotency key:", err)
+			}
+			return
+		}
+
+		record := &IdempotencyRecord{
+			RequestHash: requestHash,
+			Completed:   true,
+			Status:      recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := store.Complete(ctx, userID, key, record); err != nil {
+			log.Println("Error storing idempotent response:", err)
+		}
+	}
+}
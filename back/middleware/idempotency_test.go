@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryIdempotencyStore keeps idempotency records in memory
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, userID, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[userID+"/"+key]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+	s.records[userID+"/"+key] = &IdempotencyRecord{RequestHash: requestHash}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, userID, key string, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[userID+"/"+key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Abandon(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, userID+"/"+key)
+	return nil
+}
+
+// newIdempotentRouter serves POST /items as the user of the X-User header, counting the items created
+func newIdempotentRouter(store IdempotencyStore, status *int) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	created := 0
+
+	router := gin.New()
+	router.POST("/items", func(c *gin.Context) {
+		c.Set("userId", c.GetHeader("X-User"))
+	}, IdempotencyMiddleware(store, time.Hour), func(c *gin.Context) {
+		created++
+		c.JSON(*status, gin.H{"created": created})
+	})
+	return router, &created
+}
+
+func postItem(router *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	request.Header.Set("X-User", user)
+	if key != "" {
+		request.Header.Set(IdempotencyHeader, key)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyMiddlewareReplaysRetries(t *testing.T) {
+	status := http.StatusCreated
+	router, created := newIdempotentRouter(newMemoryIdempotencyStore(), &status)
+
+	first := postItem(router, "alice", "key-1", `{"name":"taco"}`)
+	retry := postItem(router, "alice", "key-1", `{"name":"taco"}`)
+
+	if *created != 1 {
+		t.Fatalf("expected the retry not to run the handler again, ran %d times", *created)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the first response to be replayed, got %d %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("expected the replayed response to be flagged")
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsReusedKey(t *testing.T) {
+	status := http.StatusCreated
+	router, created := newIdempotentRouter(newMemoryIdempotencyStore(), &status)
+
+	postItem(router, "alice", "key-1", `{"name":"taco"}`)
+	reused := postItem(router, "alice", "key-1", `{"name":"pizza"}`)
+
+	if reused.Code != http.StatusUnprocessableEntity || *created != 1 {
+		t.Fatalf("expected a reused key with another body to be rejected, got %d after %d creations", reused.Code, *created)
+	}
+}
+
+func TestIdempotencyMiddlewareScopesKeysPerUser(t *testing.T) {
+	status := http.StatusCreated
+	router, created := newIdempotentRouter(newMemoryIdempotencyStore(), &status)
+
+	postItem(router, "alice", "key-1", `{"name":"taco"}`)
+	postItem(router, "bob", "key-1", `{"name":"taco"}`)
+	postItem(router, "bob", "", `{"name":"taco"}`)
+
+	if *created != 3 {
+		t.Fatalf("expected keys of different users and requests without a key to run, ran %d times", *created)
+	}
+}
+
+func TestIdempotencyMiddlewareRetriesServerErrors(t *testing.T) {
+	status := http.StatusInternalServerError
+	router, created := newIdempotentRouter(newMemoryIdempotencyStore(), &status)
+
+	postItem(router, "alice", "key-1", `{}`)
+	status = http.StatusCreated
+	retry := postItem(router, "alice", "key-1", `{}`)
+
+	if retry.Code != http.StatusCreated || *created != 2 {
+		t.Fatalf("expected a failed request to be retried, got %d after %d runs", retry.Code, *created)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsRetriesInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	status := http.StatusCreated
+	router, created := newIdempotentRouter(store, &status)
+
+	// A first request claimed the key and is still being handled
+	if _, err := store.Begin(context.Background(), "alice", "key-1", hashRequest(http.MethodPost, "/items", []byte(`{}`)), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	retry := postItem(router, "alice", "key-1", `{}`)
+
+	if retry.Code != http.StatusConflict || *created != 0 {
+		t.Fatalf("expected a retry during the first request to conflict, got %d", retry.Code)
+	}
+}
@@ -0,0 +1,11 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Attendance summarizes whether a food truck showed up to its past reservations
+type Attendance struct {
+	FoodTruckID   primitive.ObjectID `json:"food_truck_id"`
+	AttendedCount int                `json:"attended_count"`
+	NoShowCount   int                `json:"no_show_count"`
+	Reservations  []Reservation      `json:"reservations"` // Past reservations, most recent first
+}
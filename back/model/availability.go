@@ -0,0 +1,21 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// DayAvailability describes the free and taken spot numbers of a parking spot on one calendar date and time slot
+type DayAvailability struct {
+	Date              time.Time          `json:"date"`
+	Slot              *TimeSlot          `json:"slot,omitempty"` // Nil when the parking spot is booked for whole days
+	LocationID        primitive.ObjectID `json:"location_id"`
+	Day               string             `json:"day_of_week"`
+	SpotID            primitive.ObjectID `json:"spot_id"`
+	MaxCapacity       int                `json:"max_capacity"`
+	RemainingCapacity int                `json:"remaining_capacity"`
+	FreeSpots         []int              `json:"free_spots"`
+	TakenSpots        []int              `json:"taken_spots"`
+	ClosedSpots       []int              `json:"closed_spots"` // Spot numbers closed on the date
+	Closed            bool               `json:"closed"`       // Every spot number is closed on the date
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// BookingPolicy configures the rules every new reservation is checked against
+type BookingPolicy struct {
+	ID            string              `bson:"_id" json:"-"`
+	LeadTimeHours int                 `bson:"lead_time_hours" json:"lead_time_hours"` // Booking less than this before the date is refused
+	MaxWeeksAhead int                 `bson:"max_weeks_ahead" json:"max_weeks_ahead"` // How far ahead a date can be booked, 0 for no limit
+	WeekStart     string              `bson:"week_start" json:"week_start"`           // First day of a calendar week for the weekly quota
+	WeeklyLimit   int                 `bson:"weekly_limit" json:"weekly_limit"`       // Reservations per food truck and calendar week, 0 for no limit
+	MonthlyLimit  int                 `bson:"monthly_limit" json:"monthly_limit"`     // Reservations per food truck and calendar month, 0 for no limit
+	BlackoutDays  []string            `bson:"blackout_days" json:"blackout_days"`     // Weekdays ("Sunday") or dates ("2026-12-25") that cannot be booked
+	Exemptions    map[string][]string `bson:"exemptions" json:"exemptions"`           // Rules skipped per user role, e.g. {"admin": ["lead_time"]}
+	UpdatedAt     time.Time           `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	UpdatedBy     primitive.ObjectID  `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// CancellationPolicy defines when a cancellation is late and what happens to users cancelling late too often
+type CancellationPolicy struct {
+	ID                       string             `bson:"_id" json:"-"`
+	CutoffHours              int                `bson:"cutoff_hours" json:"cutoff_hours"`                             // Cancelling less than this before the date is late
+	LateCancellationsAllowed int                `bson:"late_cancellations_allowed" json:"late_cancellations_allowed"` // Late cancellations tolerated per period
+	PeriodDays               int                `bson:"period_days" json:"period_days"`                               // Length of the rolling period
+	BanDays                  int                `bson:"ban_days" json:"ban_days"`                                     // Booking ban once the allowance is exceeded, 0 for none
+	UpdatedAt                time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	UpdatedBy                primitive.ObjectID `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+}
+
+// CancellationResult tells the user how the cancellation policy applied to a cancellation
+type CancellationResult struct {
+	Late                     bool       `json:"late"`
+	Deadline                 time.Time  `json:"deadline"`           // Last moment to cancel without it being late
+	LateCancellations        int        `json:"late_cancellations"` // Late cancellations in the current period, this one included
+	LateCancellationsAllowed int        `json:"late_cancellations_allowed"`
+	BannedUntil              *time.Time `json:"banned_until,omitempty"` // Set when this cancellation triggered a booking ban
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Closure blocks bookings on a range of dates, for the whole lot or only some spot numbers
+type Closure struct {
+	ID          primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	LocationID  primitive.ObjectID `json:"location_id,omitempty" bson:"location_id,omitempty"` // Closed location, empty for every location
+	StartDate   time.Time          `json:"start_date" bson:"start_date"`                       // First closed day
+	EndDate     time.Time          `json:"end_date" bson:"end_date"`                           // Last closed day, same as StartDate for a single date
+	SpotNumbers []int              `json:"spot_numbers" bson:"spot_numbers"`                   // Closed spot numbers, empty for the whole lot
+	Reason      string             `json:"reason" bson:"reason"`                               // Public holiday, market day, ...
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	CreatedBy   primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"` // Admin who created the closure
+}
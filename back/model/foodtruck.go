@@ -1,9 +1,28 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
 
 type Foodtruck struct {
 	ID     primitive.ObjectID `bson:"_id,omitempty"`
 	Name   string             `json:"name" bson:"name"`
 	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+
+	// Users the owner lets book on behalf of the truck
+	StaffIDs []primitive.ObjectID `bson:"staff_ids,omitempty" json:"staff_ids,omitempty"`
+
+	// Set by admins, the truck cannot book until then
+	SuspendedUntil *time.Time `bson:"suspended_until,omitempty" json:"suspended_until,omitempty"`
+
+	// What the truck needs from a spot, checked when booking
+	Requirements TruckRequirements `bson:"requirements" json:"requirements"`
+
+	// Spot numbers the truck is given first when booking without a spot number, most wanted first
+	PreferredSpots []int `bson:"preferred_spots,omitempty" json:"preferred_spots,omitempty"`
+
+	// Attendance counters, updated on check-in and when a reservation is marked as a no-show
+	AttendedCount int `bson:"attended_count" json:"attended_count"`
+	NoShowCount   int `bson:"no_show_count" json:"no_show_count"`
 }
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Location is a site owning its own parking spots, e.g. a business park
+type Location struct {
+	ID               primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	Name             string             `json:"name" bson:"name"`
+	Address          string             `json:"address" bson:"address"`
+	Timezone         string             `json:"timezone" bson:"timezone"`                                 // IANA name, e.g. Europe/Paris
+	OpeningDays      []string           `json:"opening_days" bson:"opening_days"`                         // Weekdays on which spots may be booked
+	IsDefault        bool               `json:"is_default" bson:"is_default"`                             // Used when a request does not name a location
+	Spots            []SpotAttributes   `json:"spots" bson:"spots"`                                       // Features of the spot numbers, see SpotAttributes
+	RequiresApproval bool               `json:"requires_approval" bson:"requires_approval"`               // New reservations stay pending until an admin approves them
+	ApprovalHours    int                `json:"approval_hours,omitempty" bson:"approval_hours,omitempty"` // Hours admins have to review a pending reservation, RESERVATION_APPROVAL_HOURS when 0
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Lottery round statuses
+const (
+	LotteryOpen    = "open"    // Accepting entries until the cutoff, direct bookings of the round's dates are refused
+	LotteryDrawing = "drawing" // Draw in progress
+	LotteryDrawn   = "drawn"   // Reservations created, see the entries
+)
+
+// Lottery entry outcomes
+const (
+	LotteryPending = "pending" // Waiting for the draw
+	LotteryWon     = "won"     // At least one preference was booked
+	LotteryLost    = "lost"    // None of the preferences could be booked
+)
+
+// LotteryRound decides the bookings of a week of a location by a weighted draw instead of first come, first served
+type LotteryRound struct {
+	ID         primitive.ObjectID   `json:"_id,omitempty" bson:"_id,omitempty"`
+	LocationID primitive.ObjectID   `json:"location_id" bson:"location_id"`                   // References Location
+	WeekStart  time.Time            `json:"week_start" bson:"week_start"`                     // First date of the week decided by the round
+	Days       []string             `json:"days" bson:"days"`                                 // Weekdays decided by the round, the whole week when empty
+	Cutoff     time.Time            `json:"cutoff" bson:"cutoff"`                             // Entries are accepted until then, the draw runs right after
+	Status     string               `json:"status" bson:"status"`                             // See Lottery* round constants
+	Seed       int64                `json:"seed,omitempty" bson:"seed,omitempty"`             // Random seed of the draw, kept so the draw can be replayed
+	DrawOrder  []primitive.ObjectID `json:"draw_order,omitempty" bson:"draw_order,omitempty"` // Entries in the order they were served
+	DrawnAt    *time.Time           `json:"drawn_at,omitempty" bson:"drawn_at,omitempty"`
+	CreatedAt  time.Time            `json:"created_at" bson:"created_at"`
+	CreatedBy  primitive.ObjectID   `json:"created_by,omitempty" bson:"created_by,omitempty"` // Admin who opened the round
+}
+
+// LotteryPreference is one wanted date of a lottery entry
+type LotteryPreference struct {
+	Date       time.Time `json:"date" bson:"date"`
+	SlotID     string    `json:"slot_id,omitempty" bson:"slot_id,omitempty"`
+	SpotNumber int       `json:"spot_number,omitempty" bson:"spot_number,omitempty"` // 0 for any spot number
+}
+
+// LotteryEntry holds the wishes of a food truck for a lottery round, most wanted first
+type LotteryEntry struct {
+	ID             primitive.ObjectID   `json:"_id,omitempty" bson:"_id,omitempty"`
+	RoundID        primitive.ObjectID   `json:"round_id" bson:"round_id"`                 // References LotteryRound
+	FoodTruckID    primitive.ObjectID   `json:"food_truck_id" bson:"food_truck_id"`       // References FoodTruck
+	UserID         primitive.ObjectID   `json:"user_id" bson:"user_id"`                   // References User
+	Preferences    []LotteryPreference  `json:"preferences" bson:"preferences"`           // Most wanted first
+	Weight         float64              `json:"weight,omitempty" bson:"weight,omitempty"` // Draw weight, set at the draw
+	Losses         int                  `json:"losses" bson:"losses"`                     // Rounds lost in a row before this one, set at the draw
+	Outcome        string               `json:"outcome" bson:"outcome"`                   // See Lottery* outcome constants
+	ReservationIDs []primitive.ObjectID `json:"reservation_ids,omitempty" bson:"reservation_ids,omitempty"`
+	Notes          []string             `json:"notes,omitempty" bson:"notes,omitempty"` // Why preferences could not be booked
+	CreatedAt      time.Time            `json:"created_at" bson:"created_at"`
+}
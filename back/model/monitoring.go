@@ -2,6 +2,7 @@ package model
 
 type Monitoring struct {
 	TotalReservations int `json:"total_reservations"`
+	TotalSpots        int `json:"total_spots"` // Weekly capacity of the stored layouts
 	AvailableSpots    int `json:"available_spots"`
 	ErrorsLogged      int `json:"errors_logged"`
 }
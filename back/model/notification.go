@@ -0,0 +1,30 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Notification types
+const (
+	NotificationReservationCancelled = "reservation_cancelled"
+	NotificationReservationRelocated = "reservation_relocated"
+	NotificationLotteryWon           = "lottery_won"
+	NotificationLotteryLost          = "lottery_lost"
+	NotificationSwapProposed         = "swap_proposed"
+	NotificationSwapAccepted         = "swap_accepted"
+	NotificationSwapDeclined         = "swap_declined"
+	NotificationReservationApproved  = "reservation_approved"
+	NotificationReservationRejected  = "reservation_rejected"
+	NotificationReservationExpired   = "reservation_expired"
+)
+
+type Notification struct {
+	ID            primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"` // Recipient
+	Type          string             `json:"type" bson:"type"`       // See Notification* constants
+	Message       string             `json:"message" bson:"message"`
+	ReservationID primitive.ObjectID `json:"reservation_id,omitempty" bson:"reservation_id,omitempty"` // Reservation the notification is about
+	Read          bool               `json:"read" bson:"read"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+}
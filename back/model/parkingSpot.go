@@ -1,12 +1,25 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
 
 type ParkingSpot struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty"`
-	Day           string             `bson:"day_of_week" json:"day_of_week"`
-	MaxCapacity   int                `bson:"max_capacity" json:"max_capacity"`
-	ReservedCount int                `bson:"reserved_count" json:"reserved_count"`
-	SpotNumbers   []int              `bson:"spot_numbers" json:"spot_numbers"`
-	ReservedSpots []int              `bson:"reserved_spots" json:"reserved_spots"`
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	LocationID  primitive.ObjectID `bson:"location_id,omitempty" json:"location_id,omitempty"` // References Location
+	Day         string             `bson:"day_of_week" json:"day_of_week"`
+	MaxCapacity int                `bson:"max_capacity" json:"max_capacity"`
+	SpotNumbers []int              `bson:"spot_numbers" json:"spot_numbers"`
+	Slots       []TimeSlot         `bson:"slots,omitempty" json:"slots,omitempty"` // Service windows of the day, the whole day when empty
+
+	// Occupancy for a concrete date, filled from SpotOccupancy when a date is requested.
+	// For a parking spot with slots and no slot requested, SlotAvailability holds one entry per slot instead.
+	SlotID           string            `bson:"-" json:"slot_id,omitempty"`
+	SlotAvailability []DayAvailability `bson:"-" json:"slot_availability,omitempty"`
+	Date             *time.Time        `bson:"-" json:"date,omitempty"`
+	ReservedCount    int               `bson:"-" json:"reserved_count"`
+	ReservedSpots    []int             `bson:"-" json:"reserved_spots"`
+	FreeSpots        []int             `bson:"-" json:"free_spots,omitempty"`
+	ClosedSpots      []int             `bson:"-" json:"closed_spots,omitempty"`
 }
@@ -5,12 +5,56 @@ import (
 	"time"
 )
 
+// Reservation statuses
+const (
+	StatusPending   = "pending"    // Requested, waiting to be confirmed
+	StatusConfirmed = "confirmed"  // Booked
+	StatusCheckedIn = "checked_in" // Truck arrived on site
+	StatusCompleted = "completed"  // Truck left after its service
+	StatusCancelled = "cancelled"  // Cancelled before the date, spot released
+	StatusNoShow    = "no_show"    // Truck never showed up, spot released
+	StatusRejected  = "rejected"   // Refused by an admin of a location requiring approval, spot released
+	StatusExpired   = "expired"    // Not reviewed before its approval deadline, spot released
+)
+
+// ReleasedStatuses no longer hold their spot number and do not count towards capacity or quotas
+var ReleasedStatuses = []string{StatusCancelled, StatusNoShow, StatusRejected, StatusExpired}
+
 type Reservation struct {
-	ID          primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	SpotID      primitive.ObjectID `json:"spot_id,omitempty" bson:"spot_id,omitempty"`             // References ParkingSpot
-	FoodTruckID primitive.ObjectID `json:"food_truck_id,omitempty" bson:"food_truck_id,omitempty"` // References FoodTruck
-	SpotNumber  int                `json:"spot_number,omitempty" bson:"spot_number,omitempty"`     // New field to specify the spot number
-	UserID      primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`             // References User
-	Date        time.Time          `json:"date,omitempty" bson:"date,omitempty"`                   // Reservation date
-	CreatedAt   time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`       // Reservation creation date
+	ID               primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	LocationID       primitive.ObjectID `json:"location_id,omitempty" bson:"location_id,omitempty"`             // References Location of the parking spot
+	SpotID           primitive.ObjectID `json:"spot_id,omitempty" bson:"spot_id,omitempty"`                     // References ParkingSpot
+	FoodTruckID      primitive.ObjectID `json:"food_truck_id,omitempty" bson:"food_truck_id,omitempty"`         // References FoodTruck
+	FoodTruckName    string             `json:"food_truck_name,omitempty" bson:"food_truck_name,omitempty"`     // Name of the food truck when it was booked
+	SpotNumber       int                `json:"spot_number,omitempty" bson:"spot_number,omitempty"`             // New field to specify the spot number
+	UserID           primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`                     // References User
+	Date             time.Time          `json:"date,omitempty" bson:"date,omitempty"`                           // Reservation date
+	SlotID           string             `json:"slot_id,omitempty" bson:"slot_id,omitempty"`                     // TimeSlot of the date, empty for the whole day
+	CreatedAt        time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`               // Reservation creation date
+	SeriesID         primitive.ObjectID `json:"series_id,omitempty" bson:"series_id,omitempty"`                 // References ReservationSeries when booked as part of one
+	Status           string             `json:"status,omitempty" bson:"status,omitempty"`                       // See Status* constants, empty on legacy reservations
+	StatusHistory    []StatusChange     `json:"status_history,omitempty" bson:"status_history,omitempty"`       // Every transition with its timestamp
+	LateCancellation bool               `json:"late_cancellation,omitempty" bson:"late_cancellation,omitempty"` // Cancelled after the policy cutoff
+	ApprovalDeadline *time.Time         `json:"approval_deadline,omitempty" bson:"approval_deadline,omitempty"` // A pending reservation expires unless reviewed by then
+}
+
+// PendingReservation is a reservation waiting for approval along with the food truck it was booked for
+type PendingReservation struct {
+	Reservation
+	FoodTruck *Foodtruck `json:"food_truck,omitempty"`
+}
+
+// StatusChange records one transition of a reservation status
+type StatusChange struct {
+	Status string             `json:"status" bson:"status"`
+	At     time.Time          `json:"at" bson:"at"`
+	By     primitive.ObjectID `json:"by,omitempty" bson:"by,omitempty"` // User who made the change
+	Reason string             `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// ReservationUpdate lists the fields of a reservation its owner may change; omitted fields are kept
+type ReservationUpdate struct {
+	Date       *time.Time `json:"date,omitempty"`
+	SpotNumber *int       `json:"spot_number,omitempty"`
+	SlotID     *string    `json:"slot_id,omitempty"` // Empty string for the whole day
 }
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ReservationHold keeps a spot number of a date and slot for a user for a few minutes while they finish booking.
+// The held number counts as taken until the hold is turned into a reservation, released or expires.
+type ReservationHold struct {
+	ID          primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	LocationID  primitive.ObjectID `json:"location_id" bson:"location_id"`     // References Location of the parking spot
+	SpotID      primitive.ObjectID `json:"spot_id" bson:"spot_id"`             // References ParkingSpot
+	FoodTruckID primitive.ObjectID `json:"food_truck_id" bson:"food_truck_id"` // References FoodTruck
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`             // References User, the only one who can use the hold
+	SpotNumber  int                `json:"spot_number" bson:"spot_number"`
+	Date        time.Time          `json:"date" bson:"date"`
+	SlotID      string             `json:"slot_id" bson:"slot_id"` // TimeSlot of the date, empty for the whole day
+	ExpiresAt   time.Time          `json:"expires_at" bson:"expires_at"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
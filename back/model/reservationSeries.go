@@ -0,0 +1,44 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Reservation series statuses
+const (
+	SeriesActive    = "active"
+	SeriesCancelled = "cancelled"
+)
+
+// Series occurrence outcomes
+const (
+	OccurrenceBooked  = "booked"
+	OccurrenceFailed  = "failed"
+	OccurrenceSkipped = "skipped"
+)
+
+// ReservationSeries books the same spot number on a fixed weekday, one Reservation per occurrence
+type ReservationSeries struct {
+	ID           primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	LocationID   primitive.ObjectID `json:"location_id,omitempty" bson:"location_id,omitempty"`     // References Location, the default one when empty
+	FoodTruckID  primitive.ObjectID `json:"food_truck_id,omitempty" bson:"food_truck_id,omitempty"` // References FoodTruck
+	UserID       primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`             // References User
+	Weekday      string             `json:"day_of_week" bson:"day_of_week"`                         // Day of the week of every occurrence
+	SpotNumber   int                `json:"spot_number" bson:"spot_number"`
+	SlotID       string             `json:"slot_id,omitempty" bson:"slot_id,omitempty"`   // TimeSlot booked on every occurrence
+	StartDate    time.Time          `json:"start_date" bson:"start_date"`                 // First possible occurrence
+	EndDate      *time.Time         `json:"end_date,omitempty" bson:"end_date,omitempty"` // Last possible occurrence, or use Count
+	Count        int                `json:"count,omitempty" bson:"count,omitempty"`       // Number of occurrences, or use EndDate
+	SkippedDates []time.Time        `json:"skipped_dates,omitempty" bson:"skipped_dates"` // Occurrences cancelled one by one
+	Status       string             `json:"status" bson:"status"`                         // See Series* constants
+	CreatedAt    time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+// SeriesOccurrence reports what happened to one date of a series
+type SeriesOccurrence struct {
+	Date          time.Time          `json:"date"`
+	Status        string             `json:"status"` // See Occurrence* constants
+	ReservationID primitive.ObjectID `json:"reservation_id,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
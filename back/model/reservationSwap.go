@@ -0,0 +1,32 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Reservation swap statuses
+const (
+	SwapProposed  = "proposed"  // Waiting for the other food truck to answer
+	SwapAccepting = "accepting" // Exchange in progress
+	SwapAccepted  = "accepted"  // Reservations exchanged
+	SwapDeclined  = "declined"  // Refused by the other food truck
+	SwapCancelled = "cancelled" // Withdrawn by the proposer
+	SwapFailed    = "failed"    // A reservation changed before the swap was accepted
+)
+
+// ReservationSwap is the proposal of a food truck to exchange one of its reservations for a reservation of another
+// food truck. Once accepted, each food truck takes the date, slot and spot number of the other.
+type ReservationSwap struct {
+	ID                  primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	ReservationID       primitive.ObjectID `json:"reservation_id" bson:"reservation_id"`               // References the Reservation offered
+	FoodTruckID         primitive.ObjectID `json:"food_truck_id" bson:"food_truck_id"`                 // References FoodTruck of the offered reservation
+	ProposerID          primitive.ObjectID `json:"proposer_id" bson:"proposer_id"`                     // References User who proposed the swap
+	TargetReservationID primitive.ObjectID `json:"target_reservation_id" bson:"target_reservation_id"` // References the Reservation wanted in exchange
+	TargetFoodTruckID   primitive.ObjectID `json:"target_food_truck_id" bson:"target_food_truck_id"`   // References FoodTruck of the wanted reservation
+	TargetUserID        primitive.ObjectID `json:"target_user_id" bson:"target_user_id"`               // References User owning the target food truck
+	Status              string             `json:"status" bson:"status"`                               // See Swap* constants
+	CreatedAt           time.Time          `json:"created_at" bson:"created_at"`
+	RespondedAt         *time.Time         `json:"responded_at,omitempty" bson:"responded_at,omitempty"`
+	RespondedBy         primitive.ObjectID `json:"responded_by,omitempty" bson:"responded_by,omitempty"` // References User who accepted or declined
+}
@@ -0,0 +1,20 @@
+package model
+
+// SpotAttributes describes the physical features of one spot number of a location
+type SpotAttributes struct {
+	Number          int     `json:"number" bson:"number"`                     // Spot number in the parking spot layouts
+	LengthM         float64 `json:"length_m" bson:"length_m"`                 // Usable length in meters, 0 when unlimited
+	WidthM          float64 `json:"width_m" bson:"width_m"`                   // Usable width in meters, 0 when unlimited
+	ElectricityAmps int     `json:"electricity_amps" bson:"electricity_amps"` // Power hookup rating, 0 without hookup
+	Water           bool    `json:"water" bson:"water"`                       // Water supply available
+	Covered         bool    `json:"covered" bson:"covered"`                   // Spot is sheltered
+}
+
+// TruckRequirements describes what a food truck needs from a spot
+type TruckRequirements struct {
+	LengthM         float64 `json:"length_m" bson:"length_m"`                 // Truck length in meters, trailer included
+	WidthM          float64 `json:"width_m" bson:"width_m"`                   // Truck width in meters
+	ElectricityAmps int     `json:"electricity_amps" bson:"electricity_amps"` // Power needed, 0 when self-powered
+	Water           bool    `json:"water" bson:"water"`                       // Needs a water supply
+	Covered         bool    `json:"covered" bson:"covered"`                   // Needs a sheltered spot
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SpotOccupancy holds the spot numbers of a ParkingSpot taken on one calendar date and time slot
+type SpotOccupancy struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SpotID        primitive.ObjectID `bson:"spot_id" json:"spot_id"` // References ParkingSpot
+	Date          time.Time          `bson:"date" json:"date"`       // Midnight UTC of the occupied day
+	SlotID        string             `bson:"slot_id" json:"slot_id"` // TimeSlot of the day, empty for the whole day
+	ReservedCount int                `bson:"reserved_count" json:"reserved_count"`
+	TakenSpots    []int              `bson:"taken_spots" json:"taken_spots"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// TimeSlot is a service window of a day, e.g. lunch from 11:00 to 15:00. Each slot of a spot number can be booked
+// by a different food truck.
+type TimeSlot struct {
+	ID          string `json:"id" bson:"id"`                                         // Short key referenced by reservations, e.g. "lunch"
+	Start       string `json:"start" bson:"start"`                                   // HH:MM in the location timezone
+	End         string `json:"end" bson:"end"`                                       // HH:MM in the location timezone
+	MaxCapacity int    `json:"max_capacity,omitempty" bson:"max_capacity,omitempty"` // Trucks per slot, the parking spot capacity when 0
+}
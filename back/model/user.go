@@ -1,6 +1,9 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
 
 type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -9,4 +12,7 @@ type User struct {
 	Email     string             `bson:"email" json:"email"`
 	Password  string             `bson:"password" json:"-" validate:"required,min=6"` // Store hashed password
 	Role      string             `bson:"role" json:"role"`                            // Role can be "admin" or "user"
+
+	BookingBannedUntil *time.Time           `bson:"booking_banned_until,omitempty" json:"booking_banned_until,omitempty"` // Set by the cancellation policy
+	ManagedLocations   []primitive.ObjectID `bson:"managed_locations,omitempty" json:"managed_locations,omitempty"`       // Locations an admin is restricted to, empty for all
 }
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Waitlist entry statuses
+const (
+	WaitlistWaiting   = "waiting"   // Queued for the date
+	WaitlistPromoting = "promoting" // Being booked after a spot was released
+	WaitlistPromoted  = "promoted"  // Booked automatically, see ReservationID
+	WaitlistCancelled = "cancelled" // Left the queue
+)
+
+type WaitlistEntry struct {
+	ID            primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	LocationID    primitive.ObjectID `json:"location_id,omitempty" bson:"location_id,omitempty"`       // References Location, the default one when empty
+	SpotID        primitive.ObjectID `json:"spot_id,omitempty" bson:"spot_id,omitempty"`               // References ParkingSpot open on the date
+	FoodTruckID   primitive.ObjectID `json:"food_truck_id,omitempty" bson:"food_truck_id,omitempty"`   // References FoodTruck
+	UserID        primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`               // References User
+	Date          time.Time          `json:"date" bson:"date"`                                         // Wanted date
+	SlotID        string             `json:"slot_id,omitempty" bson:"slot_id,omitempty"`               // Wanted TimeSlot of the date
+	SpotNumber    int                `json:"spot_number,omitempty" bson:"spot_number"`                 // Wanted spot number, 0 for any
+	Status        string             `json:"status" bson:"status"`                                     // See Waitlist* constants
+	ReservationID primitive.ObjectID `json:"reservation_id,omitempty" bson:"reservation_id,omitempty"` // Reservation created on promotion
+	Outcome       string             `json:"outcome,omitempty" bson:"outcome,omitempty"`               // Result of the last promotion attempt
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	ResolvedAt    *time.Time         `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+}
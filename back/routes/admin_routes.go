@@ -7,7 +7,7 @@ import (
 )
 
 // RegisterAdminRoutes defines admin-only routes
-func RegisterAdminRoutes(api *gin.RouterGroup, userController *controllers.UserController, logController *controllers.LogController, monitoringController *controllers.MonitoringController) {
+func RegisterAdminRoutes(api *gin.RouterGroup, userController *controllers.UserController, logController *controllers.LogController, monitoringController *controllers.MonitoringController, reservationController *controllers.ReservationController, settingsController *controllers.SettingsController) {
 	admin := api.Group("/admin")
 	admin.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"))
 	{
@@ -21,5 +21,20 @@ func RegisterAdminRoutes(api *gin.RouterGroup, userController *controllers.UserC
 
 		// Monitoring routes
 		admin.GET("/monitoring", monitoringController.FetchMonitoringDataHandler)
+
+		// On-site scanner routes
+		admin.POST("/checkin", reservationController.CheckInHandler)
+		admin.POST("/checkout", reservationController.CheckOutHandler)
+
+		// Approval queue of locations requiring approval
+		admin.GET("/approvals", reservationController.GetPendingApprovalsHandler)
+		admin.PUT("/approvals/:id/approve", reservationController.ApproveReservationHandler)
+		admin.PUT("/approvals/:id/reject", reservationController.RejectReservationHandler)
+
+		// Settings routes
+		admin.GET("/cancellation-policy", settingsController.GetCancellationPolicyHandler)
+		admin.PUT("/cancellation-policy", settingsController.UpdateCancellationPolicyHandler)
+		admin.GET("/booking-policy", settingsController.GetBookingPolicyHandler)
+		admin.PUT("/booking-policy", settingsController.UpdateBookingPolicyHandler)
 	}
 }
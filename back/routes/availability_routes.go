@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+)
+
+// RegisterAvailabilityRoutes defines the public availability routes
+func RegisterAvailabilityRoutes(api *gin.RouterGroup, parkingSpotController *controllers.ParkingSpotController) {
+	api.GET("/availability", parkingSpotController.GetAvailabilityHandler)
+}
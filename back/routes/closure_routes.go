@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
+)
+
+// RegisterClosureRoutes defines the closure routes; only admins may change closures
+func RegisterClosureRoutes(api *gin.RouterGroup, closureController *controllers.ClosureController) {
+
+	closures := api.Group("/closures", middleware.AuthMiddleware())
+	{
+		closures.GET("/", closureController.ListClosuresHandler)
+		closures.POST("/", middleware.RoleMiddleware("admin"), closureController.CreateClosureHandler)
+		closures.PUT("/:id", middleware.RoleMiddleware("admin"), closureController.UpdateClosureHandler)
+		closures.DELETE("/:id", middleware.RoleMiddleware("admin"), closureController.DeleteClosureHandler)
+	}
+}
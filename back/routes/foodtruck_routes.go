@@ -6,16 +6,19 @@ import (
 	"gitlab.com/hooly2/back/middleware"
 )
 
-func RegisterFoodtruckRoutes(api *gin.RouterGroup, foodtruckController *controllers.FoodtruckController) {
+// RegisterFoodtruckRoutes defines the food truck routes; creations can be retried safely with an Idempotency-Key
+func RegisterFoodtruckRoutes(api *gin.RouterGroup, foodtruckController *controllers.FoodtruckController, idempotency gin.HandlerFunc) {
 
 	foodtruck := api.Group("/foodtrucks", middleware.AuthMiddleware())
 	{
 		foodtruck.GET("/", foodtruckController.GetAllFoodTrucks)
 		foodtruck.GET("/:id", foodtruckController.GetFoodtruckByIDHandler)
+		foodtruck.GET("/:id/attendance", foodtruckController.GetFoodtruckAttendanceHandler)
 		foodtruck.GET("/user", foodtruckController.GetUserFoodTrucksHandler)
-		foodtruck.POST("/add", foodtruckController.CreateFoodtruck)
+		foodtruck.POST("/add", idempotency, foodtruckController.CreateFoodtruck)
 		foodtruck.PUT("/:id", foodtruckController.UpdateFoodtruck)
 		foodtruck.DELETE("/:id", foodtruckController.DeleteFoodtruck)
 		foodtruck.DELETE("/admin/:id", foodtruckController.AdminDeleteFoodtruck)
+		foodtruck.PUT("/admin/:id/suspension", middleware.RoleMiddleware("admin"), foodtruckController.SuspendFoodtruckHandler)
 	}
 }
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
+)
+
+// RegisterLocationRoutes defines the location routes; only admins may change locations
+func RegisterLocationRoutes(api *gin.RouterGroup, locationController *controllers.LocationController) {
+
+	locations := api.Group("/locations", middleware.AuthMiddleware())
+	{
+		locations.GET("/", locationController.GetLocationsHandler)
+		locations.GET("/:id", locationController.GetLocationHandler)
+		locations.POST("/", middleware.RoleMiddleware("admin"), locationController.CreateLocationHandler)
+		locations.PUT("/:id", middleware.RoleMiddleware("admin"), locationController.UpdateLocationHandler)
+		locations.PUT("/:id/spots", middleware.RoleMiddleware("admin"), locationController.UpdateSpotAttributesHandler)
+		locations.DELETE("/:id", middleware.RoleMiddleware("admin"), locationController.DeleteLocationHandler)
+	}
+
+	// Restrict an admin to some locations
+	api.PUT("/admin/users/:id/locations", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), locationController.SetManagedLocationsHandler)
+}
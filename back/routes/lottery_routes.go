@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
+)
+
+// RegisterLotteryRoutes defines the lottery routes; only admins may open, draw and verify rounds
+func RegisterLotteryRoutes(api *gin.RouterGroup, lotteryController *controllers.LotteryController) {
+
+	lotteries := api.Group("/lotteries", middleware.AuthMiddleware())
+	{
+		lotteries.GET("/", lotteryController.GetRoundsHandler)
+		lotteries.POST("/", middleware.RoleMiddleware("admin"), lotteryController.CreateRoundHandler)
+		lotteries.GET("/:id", lotteryController.GetRoundHandler)
+		lotteries.POST("/:id/entries", lotteryController.SubmitEntryHandler)
+		lotteries.POST("/:id/draw", middleware.RoleMiddleware("admin"), lotteryController.DrawHandler)
+		lotteries.GET("/:id/verify", middleware.RoleMiddleware("admin"), lotteryController.VerifyDrawHandler)
+	}
+}
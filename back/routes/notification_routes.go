@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
+)
+
+func RegisterNotificationRoutes(api *gin.RouterGroup, notificationController *controllers.NotificationController) {
+
+	notifications := api.Group("/notifications", middleware.AuthMiddleware())
+	{
+		notifications.GET("/", notificationController.GetUserNotificationsHandler)
+		notifications.PUT("/:id/read", notificationController.MarkNotificationReadHandler)
+	}
+}
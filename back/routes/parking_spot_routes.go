@@ -13,5 +13,6 @@ func RegisterParkingSpotRoutes(api *gin.RouterGroup, parkingSpotController *cont
 		parking.GET("/", parkingSpotController.ListAllParkingSpots)
 		parking.PUT("/:id/reservation", parkingSpotController.UpdateReservationStatus)
 		parking.POST("/create", parkingSpotController.CreateParkingSpotHandler)
+		parking.PUT("/:id/layout", parkingSpotController.UpdateParkingSpotLayoutHandler)
 	}
 }
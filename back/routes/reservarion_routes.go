@@ -6,18 +6,32 @@ import (
 	"gitlab.com/hooly2/back/middleware"
 )
 
-func RegisterReservationRoutes(api *gin.RouterGroup, reservationController *controllers.ReservationController) {
+// RegisterReservationRoutes defines the reservation routes; creations can be retried safely with an Idempotency-Key
+func RegisterReservationRoutes(api *gin.RouterGroup, reservationController *controllers.ReservationController, idempotency gin.HandlerFunc) {
 
 	reservation := api.Group("/reservation", middleware.AuthMiddleware())
 	{
 		reservation.GET("/admin", reservationController.GetAllReservationsHandler)
 		reservation.GET("/:id", reservationController.GetReservationByIDHandler)
 		reservation.DELETE("/admin/:id", reservationController.AdminDeleteReservationHandler)
-		reservation.POST("/", reservationController.CreateReservationHandler)
+		reservation.POST("/", idempotency, reservationController.CreateReservationHandler)
+		reservation.POST("/validate", reservationController.ValidateReservationHandler)
+		reservation.POST("/hold", reservationController.CreateHoldHandler)
+		reservation.GET("/holds", reservationController.GetUserHoldsHandler)
+		reservation.DELETE("/hold/:id", reservationController.ReleaseHoldHandler)
 		reservation.PUT("/:id", reservationController.UpdateReservationHandler)
+		reservation.PUT("/:id/status", reservationController.ChangeReservationStatusHandler)
+		reservation.PUT("/:id/transfer", reservationController.TransferReservationHandler)
+		reservation.GET("/:id/qrcode", reservationController.GetCheckinQRCodeHandler)
 		reservation.DELETE("/:id", reservationController.DeleteReservationHandler)
 		reservation.GET("/user", reservationController.GetUserReservationsHandler)
 		reservation.GET("/users", reservationController.GetAllUserReservationsHandler)
 		reservation.GET("/user/:id", reservationController.GetReservationByIDHandler)
+
+		// Recurring reservations
+		reservation.POST("/series", idempotency, reservationController.CreateReservationSeriesHandler)
+		reservation.GET("/series/:id", reservationController.GetReservationSeriesHandler)
+		reservation.DELETE("/series/:id", reservationController.CancelReservationSeriesHandler)
+		reservation.POST("/series/:id/skip", reservationController.SkipReservationSeriesDateHandler)
 	}
 }
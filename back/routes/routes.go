@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
 	"gitlab.com/hooly2/back/services"
 	"log"
 	"os"
@@ -27,7 +28,7 @@ func SetupRouter() *gin.Engine {
 	config := cors.Config{
 		AllowOrigins:     []string{allowedOrigins},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.IdempotencyHeader},
 		AllowCredentials: true,
 	}
 
@@ -39,29 +40,62 @@ func SetupRouter() *gin.Engine {
 	authService := services.NewAuthService()
 	logService := services.NewLogService()
 	monitoringService := services.NewMonitoringService()
+	locationService := services.NewLocationService()
 	foodtruckService := services.NewFoodtruckService()
 	parkingSpotService := services.NewParkingSpotService()
+	settingsService := services.NewSettingsService()
 	reservationService := services.NewReservationService()
+	waitlistService := services.NewWaitlistService(reservationService)
+	lotteryService := services.NewLotteryService(reservationService)
+	swapService := services.NewSwapService(reservationService)
+	closureService := reservationService.Closures
+	notificationService := reservationService.Notifications
+	parkingSpotService.Closures = closureService
+	parkingSpotService.Locations = locationService
+	reservationService.Locations = locationService
+	parkingSpotService.Notifications = notificationService
+	foodtruckService.Reservations = reservationService
+	userService.Reservations = reservationService
+
+	// Released spots are offered to the waitlist first
+	reservationService.OnRelease = waitlistService.PromoteNext
+
+	// Retried creations replay their first response
+	idempotency := middleware.IdempotencyMiddleware(services.NewIdempotencyService(), services.IdempotencyTTL)
 
 	// Initialize controllers
-	userController := controllers.NewUserController(userService)
+	userController := controllers.NewUserController(userService, locationService)
 	authController := controllers.NewAuthController(authService)
 	logController := controllers.NewLogController(logService)
-	monitoringController := controllers.NewMonitoringController(monitoringService)
+	monitoringController := controllers.NewMonitoringController(monitoringService, locationService)
 	foodtruckController := controllers.NewFoodtruckController(foodtruckService)
 	parkingSpotController := controllers.NewParkingSpotController(parkingSpotService)
 	reservationController := controllers.NewReservationController(reservationService)
+	waitlistController := controllers.NewWaitlistController(waitlistService)
+	settingsController := controllers.NewSettingsController(settingsService, locationService)
+	closureController := controllers.NewClosureController(closureService, reservationService)
+	notificationController := controllers.NewNotificationController(notificationService)
+	locationController := controllers.NewLocationController(locationService)
+	lotteryController := controllers.NewLotteryController(lotteryService)
+	swapController := controllers.NewSwapController(swapService)
 
 	// Define a route group for '/api'
 	api := r.Group("/api") // Create a group for '/api'
 	{
 		// Register all the routes under the '/api' group
-		RegisterAuthRoutes(api, authController)                                       // Use *gin.Engine
-		RegisterAdminRoutes(api, userController, logController, monitoringController) // Use *gin.Engine
-		RegisterUserRoutes(api, userController)                                       // Use *gin.Engine
-		RegisterFoodtruckRoutes(api, foodtruckController)                             // Use *gin.Engine
-		RegisterParkingSpotRoutes(api, parkingSpotController)                         // Use *gin.Engine
-		RegisterReservationRoutes(api, reservationController)                         // Use *gin.Engine
+		RegisterAuthRoutes(api, authController)                                                                                  // Use *gin.Engine
+		RegisterAdminRoutes(api, userController, logController, monitoringController, reservationController, settingsController) // Use *gin.Engine
+		RegisterUserRoutes(api, userController)                                                                                  // Use *gin.Engine
+		RegisterFoodtruckRoutes(api, foodtruckController, idempotency)                                                           // Use *gin.Engine
+		RegisterParkingSpotRoutes(api, parkingSpotController)                                                                    // Use *gin.Engine
+		RegisterReservationRoutes(api, reservationController, idempotency)                                                       // Use *gin.Engine
+		RegisterAvailabilityRoutes(api, parkingSpotController)                                                                   // Use *gin.Engine
+		RegisterWaitlistRoutes(api, waitlistController)                                                                          // Use *gin.Engine
+		RegisterClosureRoutes(api, closureController)                                                                            // Use *gin.Engine
+		RegisterNotificationRoutes(api, notificationController)                                                                  // Use *gin.Engine
+		RegisterLocationRoutes(api, locationController)                                                                          // Use *gin.Engine
+		RegisterLotteryRoutes(api, lotteryController)                                                                            // Use *gin.Engine
+		RegisterSwapRoutes(api, swapController)                                                                                  // Use *gin.Engine
 	}
 
 	// Return the main Gin router object, which is *gin.Engine
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
+)
+
+// RegisterSwapRoutes defines the routes of reservation swaps between food trucks
+func RegisterSwapRoutes(api *gin.RouterGroup, swapController *controllers.SwapController) {
+
+	swaps := api.Group("/swaps", middleware.AuthMiddleware())
+	{
+		swaps.POST("/", swapController.ProposeSwapHandler)
+		swaps.GET("/user", swapController.GetUserSwapsHandler)
+		swaps.PUT("/:id/accept", swapController.AcceptSwapHandler)
+		swaps.PUT("/:id/decline", swapController.DeclineSwapHandler)
+		swaps.DELETE("/:id", swapController.CancelSwapHandler)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"gitlab.com/hooly2/back/controllers"
+	"gitlab.com/hooly2/back/middleware"
+)
+
+func RegisterWaitlistRoutes(api *gin.RouterGroup, waitlistController *controllers.WaitlistController) {
+
+	waitlist := api.Group("/waitlist", middleware.AuthMiddleware())
+	{
+		waitlist.POST("/", waitlistController.JoinWaitlistHandler)
+		waitlist.GET("/user", waitlistController.GetUserWaitlistHandler)
+		waitlist.GET("/admin", waitlistController.GetWaitlistHandler)
+		waitlist.DELETE("/:id", waitlistController.LeaveWaitlistHandler)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"gitlab.com/hooly2/back/db"
 	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"time"
 )
@@ -15,7 +17,9 @@ type LogService struct {
 
 type MonitoringService struct {
 	ReservationCollection *mongo.Collection
+	ParkingSpotCollection *mongo.Collection
 	LogCollection         *mongo.Collection
+	Settings              *SettingsService
 }
 
 func NewLogService() *LogService {
@@ -27,7 +31,9 @@ func NewLogService() *LogService {
 func NewMonitoringService() *MonitoringService {
 	return &MonitoringService{
 		ReservationCollection: db.GetCollection("reservation"),
+		ParkingSpotCollection: db.GetCollection("parkingSpot"),
 		LogCollection:         db.GetCollection("log"),
+		Settings:              NewSettingsService(),
 	}
 }
 
@@ -63,20 +69,58 @@ func (ls *LogService) FetchLogs(filter map[string]interface{}) ([]model.Log, err
 	return logs, nil
 }
 
-// FetchMonitoringData aggregates monitoring data for the admin dashboard
-func (ms *MonitoringService) FetchMonitoringData() (model.Monitoring, error) {
+// FetchMonitoringData aggregates monitoring data for the admin dashboard, scoped to a location unless it is zero
+func (ms *MonitoringService) FetchMonitoringData(locationID primitive.ObjectID) (model.Monitoring, error) {
 	var monitoringData model.Monitoring
 
-	// Count total reservations
-	totalReservations, err := ms.ReservationCollection.CountDocuments(context.TODO(), bson.M{})
+	scope := bson.M{}
+	if !locationID.IsZero() {
+		scope["location_id"] = locationID
+	}
+
+	// Count total reservations still holding a spot
+	totalReservations, err := ms.ReservationCollection.CountDocuments(context.TODO(), withScope(scope, bson.M{"status": activeStatusFilter()}))
 	if err != nil {
 		return monitoringData, err
 	}
 	monitoringData.TotalReservations = int(totalReservations)
 
-	// Count available spots (total spots - total reservations)
-	totalSpots := 7*6 + 6 // 7 days of the week, 6 on Friday
-	monitoringData.AvailableSpots = totalSpots - int(totalReservations)
+	// Weekly capacity is the sum of the stored weekday layouts, counted once per slot
+	cursor, err := ms.ParkingSpotCollection.Find(context.TODO(), scope)
+	if err != nil {
+		return monitoringData, err
+	}
+	var spots []model.ParkingSpot
+	if err := cursor.All(context.TODO(), &spots); err != nil {
+		return monitoringData, err
+	}
+	for i := range spots {
+		for _, slot := range spotSlots(&spots[i]) {
+			monitoringData.TotalSpots += slotCapacity(&spots[i], slot)
+		}
+	}
+
+	// Count available spots this week (weekly capacity - reservations of the week), weeks starting as in the booking policy
+	policy, err := ms.Settings.GetBookingPolicy(context.TODO())
+	if err != nil {
+		return monitoringData, err
+	}
+	firstDay, ok := utils.ParseWeekday(policy.WeekStart)
+	if !ok {
+		firstDay = time.Monday
+	}
+	weekStart, weekEnd := utils.WeekBounds(time.Now(), firstDay)
+	weekReservations, err := ms.ReservationCollection.CountDocuments(context.TODO(), withScope(scope, bson.M{
+		"date":   bson.M{"$gte": weekStart, "$lt": weekEnd},
+		"status": activeStatusFilter(),
+	}))
+	if err != nil {
+		return monitoringData, err
+	}
+	monitoringData.AvailableSpots = monitoringData.TotalSpots - int(weekReservations)
+	if monitoringData.AvailableSpots < 0 {
+		monitoringData.AvailableSpots = 0
+	}
 
 	// Count logged errors
 	errorCount, err := ms.LogCollection.CountDocuments(context.TODO(), bson.M{"level": "ERROR"})
@@ -87,3 +131,11 @@ func (ms *MonitoringService) FetchMonitoringData() (model.Monitoring, error) {
 
 	return monitoringData, nil
 }
+
+// withScope adds the fields of a scope filter to a filter
+func withScope(scope bson.M, filter bson.M) bson.M {
+	for key, value := range scope {
+		filter[key] = value
+	}
+	return filter
+}
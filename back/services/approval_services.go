@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// defaultApprovalHours is how long admins have to review a pending reservation unless the location or
+// RESERVATION_APPROVAL_HOURS says otherwise
+const defaultApprovalHours = 48
+
+// approvalDeadline returns when a reservation booked now at a location requiring approval expires unless reviewed.
+// Reviews are due before the reserved day starts at the location whenever that leaves some time.
+func approvalDeadline(location *model.Location, date time.Time, now time.Time) time.Time {
+	hours := location.ApprovalHours
+	if hours == 0 {
+		hours = envInt("RESERVATION_APPROVAL_HOURS", defaultApprovalHours)
+	}
+	if hours == 0 {
+		hours = defaultApprovalHours
+	}
+
+	deadline := now.Add(time.Duration(hours) * time.Hour)
+	if start := dayStart(location, date); deadline.After(start) && start.After(now) {
+		deadline = start
+	}
+	return deadline
+}
+
+// GetPendingApprovals lists the reservations waiting for approval at the given locations, every location when none
+// are given, along with their food trucks. The reservations closest to their deadline come first.
+func (s *ReservationService) GetPendingApprovals(ctx context.Context, locationIDs []primitive.ObjectID) ([]model.PendingReservation, error) {
+	filter := bson.M{"status": model.StatusPending}
+	if len(locationIDs) > 0 {
+		filter["location_id"] = bson.M{"$in": locationIDs}
+	}
+
+	cursor, err := s.ReservationCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "approval_deadline", Value: 1}, {Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending reservations: %v", err)
+	}
+	var reservations []model.Reservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, fmt.Errorf("failed to fetch pending reservations: %v", err)
+	}
+
+	foodTruckIDs := make([]primitive.ObjectID, 0, len(reservations))
+	for _, reservation := range reservations {
+		foodTruckIDs = append(foodTruckIDs, reservation.FoodTruckID)
+	}
+	foodtrucks := make(map[primitive.ObjectID]*model.Foodtruck, len(foodTruckIDs))
+	if len(foodTruckIDs) > 0 {
+		cursor, err := s.FoodtruckCollection.Find(ctx, bson.M{"_id": bson.M{"$in": foodTruckIDs}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch food trucks: %v", err)
+		}
+		var found []model.Foodtruck
+		if err := cursor.All(ctx, &found); err != nil {
+			return nil, fmt.Errorf("failed to fetch food trucks: %v", err)
+		}
+		for i := range found {
+			foodtrucks[found[i].ID] = &found[i]
+		}
+	}
+
+	pending := make([]model.PendingReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		pending = append(pending, model.PendingReservation{Reservation: reservation, FoodTruck: foodtrucks[reservation.FoodTruckID]})
+	}
+	return pending, nil
+}
+
+// ApproveReservation confirms a pending reservation and tells its user
+func (s *ReservationService) ApproveReservation(ctx context.Context, reservationID primitive.ObjectID, adminID primitive.ObjectID, reason string) (*model.Reservation, error) {
+	reservation, err := s.ChangeReservationStatus(ctx, reservationID, primitive.NilObjectID, adminID, model.StatusConfirmed, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyReview(ctx, reservation, model.NotificationReservationApproved, "was approved", reason)
+	return reservation, nil
+}
+
+// RejectReservation refuses a pending reservation, releasing its spot number, and tells its user why
+func (s *ReservationService) RejectReservation(ctx context.Context, reservationID primitive.ObjectID, adminID primitive.ObjectID, reason string) (*model.Reservation, error) {
+	if reason == "" {
+		return nil, errors.New("a reason is required to reject a reservation")
+	}
+
+	reservation, err := s.ChangeReservationStatus(ctx, reservationID, primitive.NilObjectID, adminID, model.StatusRejected, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyReview(ctx, reservation, model.NotificationReservationRejected, "was rejected", reason)
+	return reservation, nil
+}
+
+// ExpirePendingReservations expires the pending reservations whose approval deadline has passed, releasing their
+// spot numbers
+func (s *ReservationService) ExpirePendingReservations(ctx context.Context, now time.Time) (int, error) {
+	cursor, err := s.ReservationCollection.Find(ctx, bson.M{
+		"status":            model.StatusPending,
+		"approval_deadline": bson.M{"$lte": now},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []model.Reservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, reservation := range reservations {
+		updated, err := s.ChangeReservationStatus(ctx, reservation.ID, primitive.NilObjectID, primitive.NilObjectID, model.StatusExpired, "not reviewed before the deadline")
+		if err != nil {
+			log.Println("Error expiring pending reservation:", err)
+			continue
+		}
+		s.notifyReview(ctx, updated, model.NotificationReservationExpired, "expired before being reviewed", "")
+		expired++
+	}
+
+	return expired, nil
+}
+
+// RunApprovalSweeper expires pending reservations past their deadline at every interval until ctx is done
+func (s *ReservationService) RunApprovalSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if expired, err := s.ExpirePendingReservations(ctx, time.Now()); err != nil {
+			log.Println("Error sweeping pending reservations:", err)
+		} else if expired > 0 {
+			log.Printf("Expired %d pending reservation(s)", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifyReview tells the user of a reservation how its review ended
+func (s *ReservationService) notifyReview(ctx context.Context, reservation *model.Reservation, kind string, outcome string, reason string) {
+	message := fmt.Sprintf("Your reservation of %s %s", reservation.Date.Format(utils.DateLayout), outcome)
+	if reason != "" {
+		message += ": " + reason
+	}
+
+	notification := model.Notification{UserID: reservation.UserID, Type: kind, ReservationID: reservation.ID, Message: message}
+	if err := s.Notifications.Notify(ctx, &notification); err != nil {
+		log.Println("Error notifying reservation review:", err)
+	}
+}
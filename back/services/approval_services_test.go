@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestApprovalDeadline(t *testing.T) {
+	t.Setenv("RESERVATION_APPROVAL_HOURS", "")
+	now := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	farDate := time.Date(2030, 1, 20, 0, 0, 0, 0, time.UTC)
+	nearDate := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		location model.Location
+		date     time.Time
+		env      string
+		want     time.Time
+	}{
+		{"default window", model.Location{}, farDate, "", now.Add(defaultApprovalHours * time.Hour)},
+		{"environment window", model.Location{}, farDate, "12", now.Add(12 * time.Hour)},
+		{"location window", model.Location{ApprovalHours: 6}, farDate, "12", now.Add(6 * time.Hour)},
+		{"due before the reserved day", model.Location{}, nearDate, "", nearDate},
+		{"reserved day already started", model.Location{ApprovalHours: 6}, time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC), "", now.Add(6 * time.Hour)},
+		{"due before the reserved day starts locally", model.Location{Timezone: "Pacific/Honolulu"}, nearDate, "", time.Date(2030, 1, 8, 10, 0, 0, 0, time.UTC)},
+		{"window ending as the reserved day starts", model.Location{ApprovalHours: 14}, nearDate, "", nearDate},
+		{"reserved day in the past", model.Location{}, time.Date(2030, 1, 6, 0, 0, 0, 0, time.UTC), "", now.Add(defaultApprovalHours * time.Hour)},
+		{"zero environment window", model.Location{}, farDate, "0", now.Add(defaultApprovalHours * time.Hour)},
+		{"invalid environment window", model.Location{}, farDate, "soon", now.Add(defaultApprovalHours * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESERVATION_APPROVAL_HOURS", tt.env)
+			if got := approvalDeadline(&tt.location, tt.date, now); !got.Equal(tt.want) {
+				t.Fatalf("approvalDeadline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// requireTestApproval makes the locations of a service require approval
+func requireTestApproval(t *testing.T, service *ReservationService) {
+	if _, err := service.Locations.LocationCollection.UpdateMany(context.Background(), bson.M{}, bson.M{"$set": bson.M{"requires_approval": true}}); err != nil {
+		t.Fatalf("failed to require approval: %v", err)
+	}
+}
+
+func TestPendingReservationHoldsItsSpot(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	requireTestApproval(t, service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+
+	if reservation.Status != model.StatusPending || reservation.ApprovalDeadline == nil {
+		t.Fatalf("expected the reservation to wait for approval, got %s", reservation.Status)
+	}
+	occupancy, err := service.Occupancy.GetOccupancy(ctx, reservation.SpotID, reservation.Date, "")
+	if err != nil {
+		t.Fatalf("failed to read occupancy: %v", err)
+	}
+	if occupancy.ReservedCount != 1 {
+		t.Fatalf("expected the pending reservation to hold its spot number, got %+v", occupancy)
+	}
+
+	pending, err := service.GetPendingApprovals(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to list pending reservations: %v", err)
+	}
+	if len(pending) != 1 || pending[0].FoodTruck == nil || pending[0].FoodTruck.ID != reservation.FoodTruckID {
+		t.Fatalf("expected the reservation to be queued with its food truck, got %+v", pending)
+	}
+
+	if _, err := service.RejectReservation(ctx, reservation.ID, primitive.NewObjectID(), ""); err == nil {
+		t.Fatal("expected a rejection without a reason to be refused")
+	}
+	approved, err := service.ApproveReservation(ctx, reservation.ID, primitive.NewObjectID(), "")
+	if err != nil {
+		t.Fatalf("failed to approve reservation: %v", err)
+	}
+	if approved.Status != model.StatusConfirmed {
+		t.Fatalf("expected the reservation to be confirmed, got %s", approved.Status)
+	}
+}
+
+func TestExpirePendingReservations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	requireTestApproval(t, service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+
+	if expired, err := service.ExpirePendingReservations(ctx, time.Now()); err != nil || expired != 0 {
+		t.Fatalf("expected nothing to expire before the deadline, got %d, %v", expired, err)
+	}
+	expired, err := service.ExpirePendingReservations(ctx, reservation.ApprovalDeadline.Add(time.Minute))
+	if err != nil || expired != 1 {
+		t.Fatalf("expected the reservation to expire, got %d, %v", expired, err)
+	}
+
+	occupancy, err := service.Occupancy.GetOccupancy(ctx, reservation.SpotID, reservation.Date, "")
+	if err != nil {
+		t.Fatalf("failed to read occupancy: %v", err)
+	}
+	if occupancy.ReservedCount != 0 {
+		t.Fatalf("expected the expired reservation to release its spot number, got %+v", occupancy)
+	}
+}
+
+func TestRebookedReservationWaitsForApprovalAgain(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	requireTestApproval(t, service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+	if _, err := service.ApproveReservation(ctx, reservation.ID, primitive.NewObjectID(), ""); err != nil {
+		t.Fatalf("failed to approve reservation: %v", err)
+	}
+
+	date := reservation.Date.AddDate(0, 0, 7)
+	moved, err := service.UpdateReservation(ctx, reservation.ID, &model.ReservationUpdate{Date: &date}, reservation.UserID)
+	if err != nil {
+		t.Fatalf("failed to move reservation: %v", err)
+	}
+	if moved.Status != model.StatusPending || moved.ApprovalDeadline == nil {
+		t.Fatalf("expected the moved reservation to wait for approval, got %s", moved.Status)
+	}
+
+	stored, err := service.GetReservationByID(ctx, reservation.ID, primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to fetch reservation: %v", err)
+	}
+	if stored.Status != model.StatusPending || stored.ApprovalDeadline == nil || !stored.ApprovalDeadline.After(time.Now()) {
+		t.Fatalf("expected a fresh approval deadline to be stored, got %s %v", stored.Status, stored.ApprovalDeadline)
+	}
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// Names of the booking policy rules, used in violations and role exemptions
+const (
+	RuleLeadTime     = "lead_time"
+	RuleMaxAhead     = "max_weeks_ahead"
+	RuleBlackoutDay  = "blackout_day"
+	RuleWeeklyQuota  = "weekly_quota"
+	RuleMonthlyQuota = "monthly_quota"
+)
+
+// bookingRuleNames lists every rule a role can be exempted from
+var bookingRuleNames = []string{RuleLeadTime, RuleMaxAhead, RuleBlackoutDay, RuleWeeklyQuota, RuleMonthlyQuota}
+
+// PolicyViolation is returned when a reservation breaks a rule of the booking policy
+type PolicyViolation struct {
+	Rule    string                 `json:"rule"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func (v *PolicyViolation) Error() string {
+	return v.Message
+}
+
+// BookingRequest is what the booking policy rules are checked against
+type BookingRequest struct {
+	Reservation *model.Reservation
+	Role        string          // Role of the user booking
+	Location    *model.Location // Location of the reservation, whose timezone decides when days start
+	Now         time.Time
+	Replaces    []primitive.ObjectID // Reservations the food truck gives up in exchange, left out of the quotas
+}
+
+// BookingRule is one check of the booking policy chain. Check returns a *PolicyViolation when the request
+// breaks the rule, or any other error when the rule could not be evaluated.
+type BookingRule interface {
+	Name() string
+	Check(ctx context.Context, request *BookingRequest) error
+}
+
+// BookingPolicyChain runs the rules of a booking policy in order, skipping those the role is exempted from
+type BookingPolicyChain struct {
+	Rules      []BookingRule
+	Exemptions map[string][]string
+}
+
+// NewBookingPolicyChain builds the rule chain of a policy, quotas being counted in the reservations collection
+func NewBookingPolicyChain(policy *model.BookingPolicy, reservations *mongo.Collection) *BookingPolicyChain {
+	weekStart, ok := utils.ParseWeekday(policy.WeekStart)
+	if !ok {
+		weekStart = time.Monday
+	}
+
+	return &BookingPolicyChain{
+		Rules: []BookingRule{
+			leadTimeRule{hours: policy.LeadTimeHours},
+			horizonRule{weeks: policy.MaxWeeksAhead},
+			blackoutRule{days: policy.BlackoutDays},
+			quotaRule{name: RuleWeeklyQuota, period: "week", limit: policy.WeeklyLimit, weekStart: weekStart, reservations: reservations},
+			quotaRule{name: RuleMonthlyQuota, period: "month", limit: policy.MonthlyLimit, reservations: reservations},
+		},
+		Exemptions: policy.Exemptions,
+	}
+}
+
+// Violations checks every rule and returns all the violations of the request
+func (c *BookingPolicyChain) Violations(ctx context.Context, request *BookingRequest) ([]*PolicyViolation, error) {
+	violations := []*PolicyViolation{}
+	for _, rule := range c.Rules {
+		if containsString(c.Exemptions[request.Role], rule.Name()) {
+			continue
+		}
+
+		err := rule.Check(ctx, request)
+		var violation *PolicyViolation
+		switch {
+		case err == nil:
+		case errors.As(err, &violation):
+			violations = append(violations, violation)
+		default:
+			return nil, err
+		}
+	}
+
+	return violations, nil
+}
+
+// QuotaRules returns the chain of the quota rules only, for changes of food truck that keep the date booked
+func (c *BookingPolicyChain) QuotaRules() *BookingPolicyChain {
+	quotas := &BookingPolicyChain{Exemptions: c.Exemptions}
+	for _, rule := range c.Rules {
+		if rule.Name() == RuleWeeklyQuota || rule.Name() == RuleMonthlyQuota {
+			quotas.Rules = append(quotas.Rules, rule)
+		}
+	}
+	return quotas
+}
+
+// Check returns the first violation of the request, if any
+func (c *BookingPolicyChain) Check(ctx context.Context, request *BookingRequest) error {
+	violations, err := c.Violations(ctx, request)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return violations[0]
+	}
+	return nil
+}
+
+// leadTimeRule refuses dates booked less than a number of hours ahead
+type leadTimeRule struct {
+	hours int
+}
+
+func (r leadTimeRule) Name() string { return RuleLeadTime }
+
+func (r leadTimeRule) Check(ctx context.Context, request *BookingRequest) error {
+	earliest := request.Now.Add(time.Duration(r.hours) * time.Hour)
+	if dayStart(request.Location, request.Reservation.Date).Before(earliest) {
+		return &PolicyViolation{
+			Rule:    RuleLeadTime,
+			Message: fmt.Sprintf("reservations must be made at least %d hours ahead", r.hours),
+			Details: map[string]interface{}{"lead_time_hours": r.hours, "earliest": earliest},
+		}
+	}
+	return nil
+}
+
+// horizonRule refuses dates further ahead than a number of weeks
+type horizonRule struct {
+	weeks int
+}
+
+func (r horizonRule) Name() string { return RuleMaxAhead }
+
+func (r horizonRule) Check(ctx context.Context, request *BookingRequest) error {
+	if r.weeks <= 0 {
+		return nil
+	}
+
+	latest := locationToday(request.Location, request.Now).AddDate(0, 0, 7*r.weeks)
+	if request.Reservation.Date.After(latest) {
+		return &PolicyViolation{
+			Rule:    RuleMaxAhead,
+			Message: fmt.Sprintf("reservations cannot be made more than %d weeks ahead", r.weeks),
+			Details: map[string]interface{}{"max_weeks_ahead": r.weeks, "latest": latest},
+		}
+	}
+	return nil
+}
+
+// blackoutRule refuses weekdays and dates that are never bookable
+type blackoutRule struct {
+	days []string
+}
+
+func (r blackoutRule) Name() string { return RuleBlackoutDay }
+
+func (r blackoutRule) Check(ctx context.Context, request *BookingRequest) error {
+	date := request.Reservation.Date
+	for _, day := range r.days {
+		if day == date.Weekday().String() || day == date.Format(utils.DateLayout) {
+			return &PolicyViolation{
+				Rule:    RuleBlackoutDay,
+				Message: fmt.Sprintf("reservations are not allowed on %s", day),
+				Details: map[string]interface{}{"blackout_day": day},
+			}
+		}
+	}
+	return nil
+}
+
+// quotaRule limits how many reservations a food truck holds per calendar week or month. Each reservation books
+// one slot, so a truck serving lunch and dinner on the same day uses two.
+type quotaRule struct {
+	name         string
+	period       string // "week" or "month"
+	limit        int
+	weekStart    time.Weekday
+	reservations *mongo.Collection
+}
+
+func (r quotaRule) Name() string { return r.name }
+
+func (r quotaRule) Check(ctx context.Context, request *BookingRequest) error {
+	if r.limit <= 0 {
+		return nil
+	}
+
+	reservation := request.Reservation
+	start, end := utils.MonthBounds(reservation.Date)
+	if r.period == "week" {
+		start, end = utils.WeekBounds(reservation.Date, r.weekStart)
+	}
+
+	// The reservation itself is ignored so that updates can be revalidated
+	filter := bson.M{
+		"food_truck_id": reservation.FoodTruckID,
+		"date":          bson.M{"$gte": start, "$lt": end},
+		"status":        activeStatusFilter(),
+	}
+	ignored := request.Replaces
+	if !reservation.ID.IsZero() {
+		ignored = append([]primitive.ObjectID{reservation.ID}, ignored...)
+	}
+	if len(ignored) > 0 {
+		filter["_id"] = bson.M{"$nin": ignored}
+	}
+
+	cursor, err := r.reservations.Find(ctx, filter)
+	if err != nil {
+		return errors.New("failed to check existing reservations")
+	}
+	defer cursor.Close(ctx)
+
+	var existing []model.Reservation
+	if err := cursor.All(ctx, &existing); err != nil {
+		return errors.New("failed to check existing reservations")
+	}
+	if len(existing) < r.limit {
+		return nil
+	}
+
+	conflicts := make([]map[string]interface{}, 0, len(existing))
+	for _, conflict := range existing {
+		conflicts = append(conflicts, map[string]interface{}{
+			"id":          conflict.ID.Hex(),
+			"spot_id":     conflict.SpotID.Hex(),
+			"spot_number": conflict.SpotNumber,
+			"slot_id":     conflict.SlotID,
+			"date":        conflict.Date,
+		})
+	}
+
+	return &PolicyViolation{
+		Rule:    r.name,
+		Message: fmt.Sprintf("food truck already has %d reservation(s) for this %s (limit %d)", len(existing), r.period, r.limit),
+		Details: map[string]interface{}{
+			"period":                   r.period,
+			"limit":                    r.limit,
+			"period_start":             start,
+			"period_end":               end,
+			"conflicting_reservations": conflicts,
+		},
+	}
+}
+
+// validateBookingPolicy checks the values of a booking policy before it is saved
+func validateBookingPolicy(policy *model.BookingPolicy) error {
+	if policy.LeadTimeHours < 0 || policy.MaxWeeksAhead < 0 || policy.WeeklyLimit < 0 || policy.MonthlyLimit < 0 {
+		return errors.New("policy values cannot be negative")
+	}
+	if _, ok := utils.ParseWeekday(policy.WeekStart); !ok {
+		return fmt.Errorf("invalid week_start %q", policy.WeekStart)
+	}
+
+	for _, day := range policy.BlackoutDays {
+		if _, ok := utils.ParseWeekday(day); ok {
+			continue
+		}
+		if _, err := time.Parse(utils.DateLayout, day); err != nil {
+			return fmt.Errorf("invalid blackout day %q, expected a weekday or YYYY-MM-DD", day)
+		}
+	}
+
+	for role, rules := range policy.Exemptions {
+		if role == "" {
+			return errors.New("exemptions need a role")
+		}
+		for _, rule := range rules {
+			if !containsString(bookingRuleNames, rule) {
+				return fmt.Errorf("unknown booking rule %q", rule)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBookingPolicy runs the booking policy chain for a new reservation at a location, on behalf of the role of its user
+func (s *ReservationService) checkBookingPolicy(ctx context.Context, reservation *model.Reservation, location *model.Location) error {
+	policy, err := s.Settings.GetBookingPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	request := &BookingRequest{
+		Reservation: reservation,
+		Role:        s.userRole(ctx, reservation.UserID),
+		Location:    location,
+		Now:         time.Now(),
+	}
+	return NewBookingPolicyChain(policy, s.ReservationCollection).Check(ctx, request)
+}
+
+// userRole returns the role of a user, empty when the user is unknown
+func (s *ReservationService) userRole(ctx context.Context, userID primitive.ObjectID) string {
+	var user model.User
+	if err := s.UserCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return ""
+	}
+	return user.Role
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"testing"
+	"time"
+)
+
+func TestBookingRules(t *testing.T) {
+	now := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		name     string
+		rule     BookingRule
+		location *model.Location
+		date     time.Time
+		wantRule string
+	}{
+		{"lead time met", leadTimeRule{hours: 24}, nil, time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC), ""},
+		{"lead time too short", leadTimeRule{hours: 24}, nil, time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC), RuleLeadTime},
+		{"no horizon", horizonRule{}, nil, time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"within horizon", horizonRule{weeks: 2}, nil, time.Date(2030, 1, 21, 0, 0, 0, 0, time.UTC), ""},
+		{"beyond horizon", horizonRule{weeks: 2}, nil, time.Date(2030, 1, 22, 0, 0, 0, 0, time.UTC), RuleMaxAhead},
+		{"blackout weekday", blackoutRule{days: []string{"Sunday"}}, nil, time.Date(2030, 1, 13, 0, 0, 0, 0, time.UTC), RuleBlackoutDay},
+		{"blackout date", blackoutRule{days: []string{"2030-01-10"}}, nil, time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC), RuleBlackoutDay},
+		{"not a blackout day", blackoutRule{days: []string{"Sunday", "2030-01-10"}}, nil, time.Date(2030, 1, 11, 0, 0, 0, 0, time.UTC), ""},
+		{"quota disabled", quotaRule{name: RuleWeeklyQuota, period: "week"}, nil, time.Date(2030, 1, 11, 0, 0, 0, 0, time.UTC), ""},
+		{"lead time met locally", leadTimeRule{hours: 24}, &model.Location{Timezone: "Pacific/Honolulu"}, time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC), ""},
+		{"lead time too short locally", leadTimeRule{hours: 12}, &model.Location{Timezone: "Pacific/Auckland"}, time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC), RuleLeadTime},
+		{"within horizon locally", horizonRule{weeks: 2}, &model.Location{Timezone: "Pacific/Kiritimati"}, time.Date(2030, 1, 22, 0, 0, 0, 0, time.UTC), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &BookingRequest{Reservation: &model.Reservation{Date: tt.date}, Location: tt.location, Now: now}
+			err := tt.rule.Check(context.Background(), request)
+
+			var violation *PolicyViolation
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("expected no violation, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &violation) || violation.Rule != tt.wantRule {
+				t.Fatalf("expected a %s violation, got %v", tt.wantRule, err)
+			}
+		})
+	}
+}
+
+// stubRule always breaks with its name
+type stubRule struct {
+	name string
+}
+
+func (r stubRule) Name() string { return r.name }
+
+func (r stubRule) Check(ctx context.Context, request *BookingRequest) error {
+	return &PolicyViolation{Rule: r.name, Message: r.name + " broken"}
+}
+
+func TestBookingPolicyChainExemptions(t *testing.T) {
+	chain := &BookingPolicyChain{
+		Rules:      []BookingRule{stubRule{RuleLeadTime}, stubRule{RuleWeeklyQuota}},
+		Exemptions: map[string][]string{"admin": {RuleLeadTime}},
+	}
+
+	violations, err := chain.Violations(context.Background(), &BookingRequest{Role: "user"})
+	if err != nil || len(violations) != 2 {
+		t.Fatalf("expected both rules to be broken for a user, got %v, %v", violations, err)
+	}
+
+	err = chain.Check(context.Background(), &BookingRequest{Role: "admin"})
+	var violation *PolicyViolation
+	if !errors.As(err, &violation) || violation.Rule != RuleWeeklyQuota {
+		t.Fatalf("expected admins to only break the quota, got %v", err)
+	}
+}
+
+func TestBookingPolicyChainQuotaRules(t *testing.T) {
+	chain := NewBookingPolicyChain(&model.BookingPolicy{LeadTimeHours: 24, WeeklyLimit: 1, MonthlyLimit: 3}, nil)
+
+	quotas := chain.QuotaRules()
+	if len(quotas.Rules) != 2 || quotas.Rules[0].Name() != RuleWeeklyQuota || quotas.Rules[1].Name() != RuleMonthlyQuota {
+		t.Fatalf("expected only the quota rules to be kept, got %v", quotas.Rules)
+	}
+	if len(chain.Rules) != 5 {
+		t.Fatalf("expected the full chain to be left untouched, got %d rules", len(chain.Rules))
+	}
+}
+
+func TestValidateBookingPolicy(t *testing.T) {
+	valid := func() model.BookingPolicy {
+		return model.BookingPolicy{
+			LeadTimeHours: 24,
+			WeekStart:     "Monday",
+			WeeklyLimit:   1,
+			BlackoutDays:  []string{"Sunday", "2030-12-25"},
+			Exemptions:    map[string][]string{"admin": {RuleLeadTime, RuleMaxAhead}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(policy *model.BookingPolicy)
+		wantErr bool
+	}{
+		{"valid policy", func(policy *model.BookingPolicy) {}, false},
+		{"negative lead time", func(policy *model.BookingPolicy) { policy.LeadTimeHours = -1 }, true},
+		{"negative horizon", func(policy *model.BookingPolicy) { policy.MaxWeeksAhead = -1 }, true},
+		{"invalid week start", func(policy *model.BookingPolicy) { policy.WeekStart = "Someday" }, true},
+		{"invalid blackout day", func(policy *model.BookingPolicy) { policy.BlackoutDays = []string{"25/12"} }, true},
+		{"unknown rule exemption", func(policy *model.BookingPolicy) { policy.Exemptions["admin"] = []string{"speed"} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := valid()
+			tt.change(&policy)
+			if err := validateBookingPolicy(&policy); (err != nil) != tt.wantErr {
+				t.Fatalf("validateBookingPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// CancelReservation cancels a reservation on behalf of its owner, applying the cancellation policy: late
+// cancellations are flagged and logged, and exceeding the allowance bans the user from booking for a while.
+func (s *ReservationService) CancelReservation(ctx context.Context, reservationID primitive.ObjectID, userID primitive.ObjectID, reason string) (*model.CancellationResult, error) {
+	policy, err := s.Settings.GetCancellationPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.GetReservationByID(ctx, reservationID, userID)
+	if err != nil {
+		return nil, errors.New("reservation not found")
+	}
+
+	location, err := s.Locations.GetLocation(ctx, reservation.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &model.CancellationResult{
+		Deadline:                 cancellationDeadline(policy, location, reservation.Date),
+		LateCancellationsAllowed: policy.LateCancellationsAllowed,
+	}
+	result.Late = now.After(result.Deadline)
+	if result.Late && reason == "" {
+		reason = "late cancellation"
+	}
+
+	// Flag a late cancellation along with the status so that it always counts towards the allowance
+	var fields bson.M
+	if result.Late {
+		fields = bson.M{"late_cancellation": true}
+	}
+	if _, err := s.changeReservationStatus(ctx, reservationID, userID, userID, model.StatusCancelled, reason, fields); err != nil {
+		return nil, err
+	}
+	if !result.Late {
+		return result, nil
+	}
+
+	if result.LateCancellations, err = s.countLateCancellations(ctx, reservation.UserID, now.AddDate(0, 0, -policy.PeriodDays)); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("late cancellation of reservation %s for %s (%d/%d in %d days)",
+		reservationID.Hex(), reservation.Date.Format("2006-01-02"), result.LateCancellations, policy.LateCancellationsAllowed, policy.PeriodDays)
+
+	// Ban the user once the allowance is exceeded
+	if bannedUntil := cancellationBan(policy, result.LateCancellations, now); bannedUntil != nil {
+		_, err := s.UserCollection.UpdateOne(ctx, bson.M{"_id": reservation.UserID}, bson.M{"$set": bson.M{"booking_banned_until": *bannedUntil}})
+		if err != nil {
+			return nil, errors.New("failed to apply booking ban")
+		}
+		result.BannedUntil = bannedUntil
+		message += fmt.Sprintf(", booking banned until %s", bannedUntil.Format(time.RFC3339))
+	}
+
+	if err := s.Logs.CreateLog("WARN", "LateCancellation", reservation.UserID.Hex(), message); err != nil {
+		log.Println("Error logging late cancellation:", err)
+	}
+
+	return result, nil
+}
+
+// checkBookingBan ensures the user is not serving a booking ban
+func (s *ReservationService) checkBookingBan(ctx context.Context, userID primitive.ObjectID) error {
+	var user model.User
+	if err := s.UserCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil
+	}
+
+	if user.BookingBannedUntil != nil && user.BookingBannedUntil.After(time.Now()) {
+		return fmt.Errorf("booking is suspended until %s after too many late cancellations", user.BookingBannedUntil.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// cancellationDeadline returns when cancelling a reservation of date at a location becomes late
+func cancellationDeadline(policy *model.CancellationPolicy, location *model.Location, date time.Time) time.Time {
+	return dayStart(location, date).Add(-time.Duration(policy.CutoffHours) * time.Hour)
+}
+
+// cancellationBan returns until when a user with the given late cancellations in the period is banned from booking,
+// nil while the allowance is not exceeded or the policy does not ban
+func cancellationBan(policy *model.CancellationPolicy, lateCancellations int, now time.Time) *time.Time {
+	if lateCancellations <= policy.LateCancellationsAllowed || policy.BanDays <= 0 {
+		return nil
+	}
+	bannedUntil := now.AddDate(0, 0, policy.BanDays)
+	return &bannedUntil
+}
+
+// countLateCancellations counts the reservations of a user cancelled late since the given instant
+func (s *ReservationService) countLateCancellations(ctx context.Context, userID primitive.ObjectID, since time.Time) (int, error) {
+	count, err := s.ReservationCollection.CountDocuments(ctx, bson.M{
+		"user_id":           userID,
+		"late_cancellation": true,
+		"status_history":    bson.M{"$elemMatch": bson.M{"status": model.StatusCancelled, "at": bson.M{"$gte": since}}},
+	})
+	if err != nil {
+		return 0, errors.New("failed to count late cancellations")
+	}
+	return int(count), nil
+}
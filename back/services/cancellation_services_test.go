@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestCancellationDeadline(t *testing.T) {
+	date := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		cutoffHours int
+		location    *model.Location
+		want        time.Time
+	}{
+		{"cutoff before the day", 48, &model.Location{Timezone: "UTC"}, time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"no cutoff", 0, &model.Location{Timezone: "UTC"}, date},
+		{"day starting after utc", 48, &model.Location{Timezone: "Pacific/Honolulu"}, time.Date(2030, 1, 8, 10, 0, 0, 0, time.UTC)},
+		{"day starting before utc", 12, &model.Location{Timezone: "Pacific/Auckland"}, time.Date(2030, 1, 8, 23, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &model.CancellationPolicy{CutoffHours: tt.cutoffHours}
+			if got := cancellationDeadline(policy, tt.location, date); !got.Equal(tt.want) {
+				t.Fatalf("cancellationDeadline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancellationBan(t *testing.T) {
+	now := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	policy := model.CancellationPolicy{LateCancellationsAllowed: 2, BanDays: 14}
+	bannedUntil := now.AddDate(0, 0, 14)
+
+	tests := []struct {
+		name              string
+		banDays           int
+		lateCancellations int
+		want              *time.Time
+	}{
+		{"within the allowance", 14, 1, nil},
+		{"allowance reached", 14, 2, nil},
+		{"allowance exceeded", 14, 3, &bannedUntil},
+		{"policy without ban", 0, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := policy
+			policy.BanDays = tt.banDays
+			got := cancellationBan(&policy, tt.lateCancellations, now)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Fatalf("cancellationBan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountLateCancellations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond) // MongoDB stores milliseconds
+	since := now.AddDate(0, 0, -30)
+
+	tests := []struct {
+		name        string
+		late        bool
+		status      string
+		cancelledAt time.Time
+		want        int
+	}{
+		{"late within the period", true, model.StatusCancelled, now.AddDate(0, 0, -1), 1},
+		{"late at the start of the period", true, model.StatusCancelled, since, 1},
+		{"late before the period", true, model.StatusCancelled, since.Add(-time.Minute), 0},
+		{"cancelled in time", false, model.StatusCancelled, now.AddDate(0, 0, -1), 0},
+		{"not a cancellation", true, model.StatusNoShow, now.AddDate(0, 0, -1), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := primitive.NewObjectID()
+			_, err := service.ReservationCollection.InsertOne(ctx, bson.M{
+				"user_id":           userID,
+				"status":            tt.status,
+				"late_cancellation": tt.late,
+				"status_history":    []model.StatusChange{{Status: tt.status, At: tt.cancelledAt}},
+			})
+			if err != nil {
+				t.Fatalf("failed to insert reservation: %v", err)
+			}
+
+			got, err := service.countLateCancellations(ctx, userID, since)
+			if err != nil {
+				t.Fatalf("failed to count late cancellations: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("countLateCancellations() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelReservationFlagsLateCancellation(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	policy := &model.CancellationPolicy{CutoffHours: 96, LateCancellationsAllowed: 0, PeriodDays: 30, BanDays: 7}
+	if err := service.Settings.UpdateCancellationPolicy(ctx, policy, primitive.NewObjectID()); err != nil {
+		t.Fatalf("failed to save cancellation policy: %v", err)
+	}
+
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1})
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	reservation := &model.Reservation{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 1, Date: date, LateCancellation: true}
+	if err := service.CreateReservation(ctx, reservation); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+	if reservation.LateCancellation {
+		t.Fatal("expected a new reservation not to be flagged as a late cancellation")
+	}
+
+	result, err := service.CancelReservation(ctx, reservation.ID, userID, "")
+	if err != nil {
+		t.Fatalf("failed to cancel reservation: %v", err)
+	}
+	if !result.Late || result.LateCancellations != 1 || result.BannedUntil == nil {
+		t.Fatalf("expected a late cancellation exceeding the allowance, got %+v", result)
+	}
+
+	stored, err := service.GetReservationByID(ctx, reservation.ID, primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to fetch reservation: %v", err)
+	}
+	if stored.Status != model.StatusCancelled || !stored.LateCancellation {
+		t.Fatalf("expected the reservation to be cancelled and flagged, got %s, %t", stored.Status, stored.LateCancellation)
+	}
+}
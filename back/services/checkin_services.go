@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// qrCodeScale is the size in pixels of one QR code module
+const qrCodeScale = 8
+
+// defaultNoShowWindowDays is how many past days the no-show sweep looks at unless NO_SHOW_WINDOW_DAYS says otherwise
+const defaultNoShowWindowDays = 7
+
+// GetCheckinQRCode renders the signed check-in token of a reservation as a PNG QR code, scoped by user ID when given
+func (s *ReservationService) GetCheckinQRCode(ctx context.Context, reservationID primitive.ObjectID, userID primitive.ObjectID) ([]byte, error) {
+	reservation, err := s.GetReservationByID(ctx, reservationID, userID)
+	if err != nil {
+		return nil, errors.New("reservation not found")
+	}
+
+	if status := reservationStatus(reservation); status != model.StatusConfirmed && status != model.StatusCheckedIn {
+		return nil, fmt.Errorf("no check-in code for a reservation that is %s", status)
+	}
+
+	token, err := utils.GenerateCheckinToken(reservation.ID.Hex(), reservation.Date.Format(utils.DateLayout), reservation.SpotNumber)
+	if err != nil {
+		return nil, err
+	}
+	return utils.QRCodePNG(token, qrCodeScale)
+}
+
+// CheckIn validates a scanned token against its reservation, today's date and the scanned spot number
+// (0 to skip the spot check), then marks the truck as arrived.
+func (s *ReservationService) CheckIn(ctx context.Context, token string, spotNumber int, adminID primitive.ObjectID) (*model.Reservation, error) {
+	reservation, err := s.reservationFromToken(ctx, token, spotNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ChangeReservationStatus(ctx, reservation.ID, primitive.NilObjectID, adminID, model.StatusCheckedIn, "checked in on site")
+}
+
+// CheckOut validates a scanned token and marks the truck as gone
+func (s *ReservationService) CheckOut(ctx context.Context, token string, spotNumber int, adminID primitive.ObjectID) (*model.Reservation, error) {
+	reservation, err := s.reservationFromToken(ctx, token, spotNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ChangeReservationStatus(ctx, reservation.ID, primitive.NilObjectID, adminID, model.StatusCompleted, "checked out on site")
+}
+
+// reservationFromToken loads the reservation of a check-in token, ensuring it matches the reservation date and spot
+func (s *ReservationService) reservationFromToken(ctx context.Context, token string, spotNumber int) (*model.Reservation, error) {
+	content, err := utils.ParseCheckinToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	reservationID, err := primitive.ObjectIDFromHex(content.ReservationID)
+	if err != nil {
+		return nil, errors.New("malformed check-in token")
+	}
+
+	reservation, err := s.GetReservationByID(ctx, reservationID, primitive.NilObjectID)
+	if err != nil {
+		return nil, errors.New("reservation not found")
+	}
+
+	date := reservation.Date.Format(utils.DateLayout)
+	if content.Date != date || content.SpotNumber != reservation.SpotNumber {
+		return nil, errors.New("check-in token does not match the reservation")
+	}
+	today, err := s.todayAt(ctx, reservation.LocationID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if date != today.Format(utils.DateLayout) {
+		return nil, fmt.Errorf("check-in token is only valid on %s", date)
+	}
+	if spotNumber != 0 && spotNumber != reservation.SpotNumber {
+		return nil, fmt.Errorf("truck is booked on spot %d, not spot %d", reservation.SpotNumber, spotNumber)
+	}
+
+	return reservation, nil
+}
+
+// MarkNoShows flags the confirmed reservations dated in the few days before the day it is at their location at the
+// given instant as no-shows. Legacy reservations without a status predate check-in and are never flagged, neither
+// are those older than NO_SHOW_WINDOW_DAYS.
+func (s *ReservationService) MarkNoShows(ctx context.Context, before time.Time) (int, error) {
+	// Locations ahead of UTC may already be on the next day
+	day := utils.TruncateToDay(before).AddDate(0, 0, 1)
+	cursor, err := s.ReservationCollection.Find(ctx, bson.M{
+		"date": bson.M{
+			"$gte": day.AddDate(0, 0, -1-envInt("NO_SHOW_WINDOW_DAYS", defaultNoShowWindowDays)),
+			"$lt":  day,
+		},
+		"status": model.StatusConfirmed,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []model.Reservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, reservation := range reservations {
+		today, err := s.todayAt(ctx, reservation.LocationID, before)
+		if err != nil {
+			log.Println("Error marking reservation as no-show:", err)
+			continue
+		}
+		if !reservation.Date.Before(today) {
+			continue
+		}
+
+		_, err = s.ChangeReservationStatus(ctx, reservation.ID, primitive.NilObjectID, primitive.NilObjectID, model.StatusNoShow, "did not check in")
+		if err != nil {
+			log.Println("Error marking reservation as no-show:", err)
+			continue
+		}
+		marked++
+	}
+
+	return marked, nil
+}
+
+// RunNoShowSweeper marks no-shows of past days at every interval until ctx is done
+func (s *ReservationService) RunNoShowSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if marked, err := s.MarkNoShows(ctx, time.Now()); err != nil {
+			log.Println("Error sweeping no-shows:", err)
+		} else if marked > 0 {
+			log.Printf("Marked %d reservation(s) as no-show", marked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordAttendance keeps the attendance counters of the food truck in line with a status change
+func (s *ReservationService) recordAttendance(ctx context.Context, reservation *model.Reservation, status string) {
+	var field string
+	switch status {
+	case model.StatusCheckedIn:
+		field = "attended_count"
+	case model.StatusNoShow:
+		field = "no_show_count"
+	default:
+		return
+	}
+
+	_, err := s.FoodtruckCollection.UpdateOne(ctx, bson.M{"_id": reservation.FoodTruckID}, bson.M{"$inc": bson.M{field: 1}})
+	if err != nil {
+		log.Println("Error updating food truck attendance:", err)
+	}
+}
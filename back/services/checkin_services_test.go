@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestMarkNoShowsSkipsLegacyAndOldReservations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	today := utils.TruncateToDay(time.Now())
+
+	recent := primitive.NewObjectID()
+	legacy := primitive.NewObjectID()
+	old := primitive.NewObjectID()
+	_, err := service.ReservationCollection.InsertMany(ctx, []interface{}{
+		bson.M{"_id": recent, "date": today.AddDate(0, 0, -1), "status": model.StatusConfirmed},
+		bson.M{"_id": legacy, "date": today.AddDate(0, 0, -1)},
+		bson.M{"_id": old, "date": today.AddDate(0, 0, -defaultNoShowWindowDays-1), "status": model.StatusConfirmed},
+	})
+	if err != nil {
+		t.Fatalf("failed to insert reservations: %v", err)
+	}
+
+	marked, err := service.MarkNoShows(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("failed to mark no-shows: %v", err)
+	}
+	if marked != 1 {
+		t.Fatalf("expected only the recent confirmed reservation to be marked, got %d", marked)
+	}
+
+	expected := map[primitive.ObjectID]string{recent: model.StatusNoShow, legacy: "", old: model.StatusConfirmed}
+	for id, status := range expected {
+		reservation, err := service.GetReservationByID(ctx, id, primitive.NilObjectID)
+		if err != nil {
+			t.Fatalf("failed to fetch reservation: %v", err)
+		}
+		if reservation.Status != status {
+			t.Fatalf("expected reservation %s to be %q, got %q", id.Hex(), status, reservation.Status)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// maxClosureRange bounds the length of a single closure
+const maxClosureRange = 366 * 24 * time.Hour
+
+// ErrSpotClosed is returned when booking a date or spot number that is closed
+var ErrSpotClosed = errors.New("spot is closed on this day")
+
+// ClosureService manages the dates on which the lot, or some of its spot numbers, cannot be booked
+type ClosureService struct {
+	ClosureCollection *mongo.Collection
+}
+
+func NewClosureService() *ClosureService {
+	return &ClosureService{
+		ClosureCollection: db.GetCollection("closure"),
+	}
+}
+
+// CreateClosure validates and stores a new closure
+func (s *ClosureService) CreateClosure(ctx context.Context, closure *model.Closure) error {
+	if err := normalizeClosure(closure); err != nil {
+		return err
+	}
+
+	closure.ID = primitive.NewObjectID()
+	closure.CreatedAt = time.Now()
+	if _, err := s.ClosureCollection.InsertOne(ctx, closure); err != nil {
+		return fmt.Errorf("failed to create closure: %v", err)
+	}
+
+	return nil
+}
+
+// GetClosure retrieves a closure by ID
+func (s *ClosureService) GetClosure(ctx context.Context, closureID primitive.ObjectID) (*model.Closure, error) {
+	var closure model.Closure
+	if err := s.ClosureCollection.FindOne(ctx, bson.M{"_id": closureID}).Decode(&closure); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("closure not found")
+		}
+		return nil, err
+	}
+
+	return &closure, nil
+}
+
+// UpdateClosure replaces the location, dates, spot numbers and reason of a closure
+func (s *ClosureService) UpdateClosure(ctx context.Context, closureID primitive.ObjectID, closure *model.Closure) error {
+	if err := normalizeClosure(closure); err != nil {
+		return err
+	}
+
+	fields := bson.M{
+		"start_date":   closure.StartDate,
+		"end_date":     closure.EndDate,
+		"spot_numbers": closure.SpotNumbers,
+		"reason":       closure.Reason,
+	}
+	update := bson.M{"$set": fields}
+	// A closure of every location has no location_id at all
+	if closure.LocationID.IsZero() {
+		update["$unset"] = bson.M{"location_id": ""}
+	} else {
+		fields["location_id"] = closure.LocationID
+	}
+	err := s.ClosureCollection.FindOneAndUpdate(ctx, bson.M{"_id": closureID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(closure)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("closure not found")
+		}
+		return fmt.Errorf("failed to update closure: %v", err)
+	}
+
+	return nil
+}
+
+// BackfillLocations removes the zero location ID stored by updates on closures of every location
+func (s *ClosureService) BackfillLocations(ctx context.Context) error {
+	_, err := s.ClosureCollection.UpdateMany(ctx, bson.M{"location_id": primitive.NilObjectID}, bson.M{"$unset": bson.M{"location_id": ""}})
+	if err != nil {
+		return fmt.Errorf("failed to backfill closure locations: %v", err)
+	}
+	return nil
+}
+
+// DeleteClosure reopens the dates of a closure
+func (s *ClosureService) DeleteClosure(ctx context.Context, closureID primitive.ObjectID) error {
+	result, err := s.ClosureCollection.DeleteOne(ctx, bson.M{"_id": closureID})
+	if err != nil {
+		return fmt.Errorf("failed to delete closure: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("closure not found")
+	}
+
+	return nil
+}
+
+// ListClosures returns the closures overlapping the range between from and to, any bound may be zero.
+// With a location, only the closures of that location and those of every location are returned.
+func (s *ClosureService) ListClosures(ctx context.Context, locationID primitive.ObjectID, from, to time.Time) ([]model.Closure, error) {
+	filter := bson.M{}
+	if !locationID.IsZero() {
+		filter["location_id"] = bson.M{"$in": []interface{}{locationID, nil}}
+	}
+	if !to.IsZero() {
+		filter["start_date"] = bson.M{"$lte": utils.TruncateToDay(to)}
+	}
+	if !from.IsZero() {
+		filter["end_date"] = bson.M{"$gte": utils.TruncateToDay(from)}
+	}
+
+	cursor, err := s.ClosureCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch closures: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	closures := []model.Closure{}
+	if err := cursor.All(ctx, &closures); err != nil {
+		return nil, fmt.Errorf("failed to decode closures: %v", err)
+	}
+
+	return closures, nil
+}
+
+// CheckOpen returns ErrSpotClosed, with the reason, when a spot number of a location is closed on a date
+func (s *ClosureService) CheckOpen(ctx context.Context, locationID primitive.ObjectID, date time.Time, spotNumber int) error {
+	closures, err := s.ListClosures(ctx, locationID, date, date)
+	if err != nil {
+		return err
+	}
+
+	for i := range closures {
+		if closureCovers(&closures[i], date, spotNumber) {
+			return fmt.Errorf("%w: %s", ErrSpotClosed, closures[i].Reason)
+		}
+	}
+
+	return nil
+}
+
+// normalizeClosure truncates the dates of a closure to whole days and validates them
+func normalizeClosure(closure *model.Closure) error {
+	if closure.StartDate.IsZero() {
+		return errors.New("start_date is required")
+	}
+	if closure.Reason == "" {
+		return errors.New("reason is required")
+	}
+
+	closure.StartDate = utils.TruncateToDay(closure.StartDate)
+	if closure.EndDate.IsZero() {
+		closure.EndDate = closure.StartDate
+	}
+	closure.EndDate = utils.TruncateToDay(closure.EndDate)
+
+	if closure.EndDate.Before(closure.StartDate) {
+		return errors.New("end_date must not be before start_date")
+	}
+	if closure.EndDate.Sub(closure.StartDate) > maxClosureRange {
+		return fmt.Errorf("a closure cannot exceed %d days", int(maxClosureRange.Hours()/24))
+	}
+	if closure.SpotNumbers == nil {
+		closure.SpotNumbers = []int{}
+	}
+
+	return nil
+}
+
+// closureCovers reports whether a closure applies to a spot number on a date
+func closureCovers(closure *model.Closure, date time.Time, spotNumber int) bool {
+	date = utils.TruncateToDay(date)
+	if date.Before(closure.StartDate.UTC()) || date.After(closure.EndDate.UTC()) {
+		return false
+	}
+
+	return len(closure.SpotNumbers) == 0 || containsInt(closure.SpotNumbers, spotNumber)
+}
+
+// closuresOfLocation keeps the closures applying to a location, including those of every location
+func closuresOfLocation(closures []model.Closure, locationID primitive.ObjectID) []model.Closure {
+	kept := []model.Closure{}
+	for _, closure := range closures {
+		if closure.LocationID.IsZero() || closure.LocationID == locationID {
+			kept = append(kept, closure)
+		}
+	}
+	return kept
+}
+
+// closedSpotNumbers lists the spot numbers of a layout closed on a date
+func closedSpotNumbers(closures []model.Closure, date time.Time, spotNumbers []int) []int {
+	closed := []int{}
+	for _, num := range spotNumbers {
+		for i := range closures {
+			if closureCovers(&closures[i], date, num) {
+				closed = append(closed, num)
+				break
+			}
+		}
+	}
+
+	return closed
+}
+
+// CancelReservationsInClosure cancels the active reservations falling inside a closure and notifies their owners.
+// It returns the cancelled reservations.
+func (s *ReservationService) CancelReservationsInClosure(ctx context.Context, closure *model.Closure, adminID primitive.ObjectID) ([]model.Reservation, error) {
+	filter := bson.M{
+		"date":   bson.M{"$gte": closure.StartDate, "$lte": closure.EndDate},
+		"status": activeStatusFilter(),
+	}
+	if !closure.LocationID.IsZero() {
+		filter["location_id"] = closure.LocationID
+	}
+	if len(closure.SpotNumbers) > 0 {
+		filter["spot_number"] = bson.M{"$in": closure.SpotNumbers}
+	}
+
+	cursor, err := s.ReservationCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reservations: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var affected []model.Reservation
+	if err = cursor.All(ctx, &affected); err != nil {
+		return nil, fmt.Errorf("failed to decode reservations: %v", err)
+	}
+
+	reason := "lot closed: " + closure.Reason
+	cancelled := []model.Reservation{}
+	for _, reservation := range affected {
+		updated, err := s.ChangeReservationStatus(ctx, reservation.ID, primitive.NilObjectID, adminID, model.StatusCancelled, reason)
+		if err != nil {
+			// Checked-in reservations cannot be cancelled anymore; leave them to the admin
+			var transitionErr *InvalidTransitionError
+			if errors.As(err, &transitionErr) {
+				continue
+			}
+			return cancelled, err
+		}
+		cancelled = append(cancelled, *updated)
+
+		notification := model.Notification{
+			UserID:        reservation.UserID,
+			Type:          model.NotificationReservationCancelled,
+			ReservationID: reservation.ID,
+			Message: fmt.Sprintf("Your reservation of spot %d on %s was cancelled because the lot is closed: %s",
+				reservation.SpotNumber, reservation.Date.Format(utils.DateLayout), closure.Reason),
+		}
+		if err := s.Notifications.Notify(ctx, &notification); err != nil {
+			return cancelled, err
+		}
+	}
+
+	return cancelled, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClosureCovers(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, time.May, d, 0, 0, 0, 0, time.UTC) }
+	wholeLot := &model.Closure{StartDate: day(1), EndDate: day(3)}
+	someSpots := &model.Closure{StartDate: day(8), EndDate: day(8), SpotNumbers: []int{2, 5}}
+
+	tests := []struct {
+		name       string
+		closure    *model.Closure
+		date       time.Time
+		spotNumber int
+		expected   bool
+	}{
+		{"before range", wholeLot, day(1).Add(-time.Hour), 1, false},
+		{"first day", wholeLot, day(1), 1, true},
+		{"time of day ignored", wholeLot, day(3).Add(15 * time.Hour), 7, true},
+		{"after range", wholeLot, day(4), 1, false},
+		{"closed spot number", someSpots, day(8), 5, true},
+		{"open spot number", someSpots, day(8), 3, false},
+		{"any spot number on partial closure", someSpots, day(8), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := closureCovers(tt.closure, tt.date, tt.spotNumber); got != tt.expected {
+				t.Fatalf("closureCovers() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClosedSpotNumbers(t *testing.T) {
+	date := time.Date(2025, time.May, 8, 0, 0, 0, 0, time.UTC)
+	closures := []model.Closure{
+		{StartDate: date, EndDate: date, SpotNumbers: []int{2}},
+		{StartDate: date.AddDate(0, 0, -2), EndDate: date, SpotNumbers: []int{5, 2}},
+		{StartDate: date.AddDate(0, 0, 1), EndDate: date.AddDate(0, 0, 1)},
+	}
+
+	closed := closedSpotNumbers(closures, date, []int{1, 2, 3, 4, 5, 6, 7})
+	if !reflect.DeepEqual(closed, []int{2, 5}) {
+		t.Fatalf("expected spots [2 5] closed, got %v", closed)
+	}
+
+	closed = closedSpotNumbers(closures, date.AddDate(0, 0, 1), []int{1, 2, 3})
+	if !reflect.DeepEqual(closed, []int{1, 2, 3}) {
+		t.Fatalf("expected every spot closed, got %v", closed)
+	}
+}
+
+func TestNormalizeClosure(t *testing.T) {
+	start := time.Date(2025, time.May, 8, 14, 30, 0, 0, time.UTC)
+	closure := &model.Closure{StartDate: start, Reason: "Market day"}
+	if err := normalizeClosure(closure); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !closure.StartDate.Equal(closure.EndDate) || closure.StartDate.Hour() != 0 {
+		t.Fatalf("expected a single whole day, got %v - %v", closure.StartDate, closure.EndDate)
+	}
+
+	closure = &model.Closure{StartDate: start, EndDate: start.AddDate(0, 0, -1), Reason: "Holiday"}
+	if err := normalizeClosure(closure); err == nil {
+		t.Fatal("expected an error when end_date is before start_date")
+	}
+
+	closure = &model.Closure{StartDate: start}
+	if err := normalizeClosure(closure); err == nil {
+		t.Fatal("expected an error without a reason")
+	}
+}
+
+func TestUpdateClosureToEveryLocation(t *testing.T) {
+	service := &ClosureService{ClosureCollection: newTestDatabase(t).Collection("closure")}
+	ctx := context.Background()
+	day := time.Now().AddDate(0, 0, 10)
+	location := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+
+	closure := &model.Closure{LocationID: location, StartDate: day, Reason: "Market day"}
+	if err := service.CreateClosure(ctx, closure); err != nil {
+		t.Fatalf("failed to create closure: %v", err)
+	}
+	if err := service.CheckOpen(ctx, other, day, 1); err != nil {
+		t.Fatalf("expected another location to stay open, got %v", err)
+	}
+
+	update := &model.Closure{StartDate: day, Reason: "Public holiday"}
+	if err := service.UpdateClosure(ctx, closure.ID, update); err != nil {
+		t.Fatalf("failed to update closure: %v", err)
+	}
+	if !update.LocationID.IsZero() {
+		t.Fatalf("expected the closure to have no location, got %s", update.LocationID.Hex())
+	}
+	if err := service.CheckOpen(ctx, other, day, 1); !errors.Is(err, ErrSpotClosed) {
+		t.Fatalf("expected every location to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"os"
+	"time"
+)
+
+// What happens to the future reservations of a deleted food truck or user
+const (
+	CascadeBlock    = "block"    // Refuse the deletion while future reservations exist
+	CascadeCancel   = "cancel"   // Cancel them, releasing their spot numbers
+	CascadeTransfer = "transfer" // Give them, and the food trucks, to another user
+)
+
+var (
+	// ErrDeletionBlocked is returned when future reservations keep a food truck or user from being deleted
+	ErrDeletionBlocked = errors.New("future reservations must be cancelled or transferred first")
+	// ErrInvalidCascade is returned for an unknown cascade mode or an unusable transfer target
+	ErrInvalidCascade = errors.New("invalid cascade")
+	// ErrTransferForbidden is returned when a user other than an admin asks for a transfer
+	ErrTransferForbidden = errors.New("only admins can transfer food trucks and reservations to another user")
+)
+
+// CascadeOptions tells how a deletion handles what depends on the deleted food truck or user
+type CascadeOptions struct {
+	Mode       string             // See Cascade* constants, DefaultCascadeMode when empty
+	TransferTo primitive.ObjectID // User receiving the food trucks and reservations in transfer mode
+	ActorID    primitive.ObjectID // User asking for the deletion
+}
+
+// DeletionReport describes what a deletion did, or the reservations blocking it
+type DeletionReport struct {
+	Mode                    string               `json:"mode"`
+	Deleted                 bool                 `json:"deleted"`
+	BlockingReservations    []primitive.ObjectID `json:"blocking_reservations,omitempty"`
+	CancelledReservations   []primitive.ObjectID `json:"cancelled_reservations"`
+	TransferredReservations []primitive.ObjectID `json:"transferred_reservations"`
+	DeletedFoodtrucks       []primitive.ObjectID `json:"deleted_foodtrucks"`
+	TransferredFoodtrucks   []primitive.ObjectID `json:"transferred_foodtrucks"`
+	ReleasedHolds           int                  `json:"released_holds"`
+	RemovedWaitlistEntries  int                  `json:"removed_waitlist_entries"`
+}
+
+// DefaultCascadeMode reads the cascade mode used when a deletion does not name one from the DELETION_CASCADE
+// environment variable, blocking deletions by default
+func DefaultCascadeMode() string {
+	mode := os.Getenv("DELETION_CASCADE")
+	switch mode {
+	case CascadeBlock, CascadeCancel, CascadeTransfer:
+		return mode
+	case "":
+	default:
+		log.Printf("Invalid DELETION_CASCADE, using %s: %s", CascadeBlock, mode)
+	}
+	return CascadeBlock
+}
+
+// newDeletionReport checks the cascade options and starts the report of a deletion
+func (s *ReservationService) newDeletionReport(ctx context.Context, options *CascadeOptions, deletedUserID primitive.ObjectID) (*DeletionReport, error) {
+	if err := checkCascadeOptions(options, s.userRole(ctx, options.ActorID), deletedUserID); err != nil {
+		return nil, err
+	}
+	if options.Mode == CascadeTransfer {
+		if count, err := s.UserCollection.CountDocuments(ctx, bson.M{"_id": options.TransferTo}); err != nil {
+			return nil, err
+		} else if count == 0 {
+			return nil, fmt.Errorf("%w: transfer_to user not found", ErrInvalidCascade)
+		}
+	}
+
+	return &DeletionReport{
+		Mode:                    options.Mode,
+		CancelledReservations:   []primitive.ObjectID{},
+		TransferredReservations: []primitive.ObjectID{},
+		DeletedFoodtrucks:       []primitive.ObjectID{},
+		TransferredFoodtrucks:   []primitive.ObjectID{},
+	}, nil
+}
+
+// checkCascadeOptions falls back to the default cascade mode when none is given and checks what the mode needs,
+// actorRole being the role of the user deleting
+func checkCascadeOptions(options *CascadeOptions, actorRole string, deletedUserID primitive.ObjectID) error {
+	if options.Mode == "" {
+		options.Mode = DefaultCascadeMode()
+	}
+
+	switch options.Mode {
+	case CascadeBlock, CascadeCancel:
+	case CascadeTransfer:
+		// The receiving user has no say, so only admins may hand things over
+		if actorRole != "admin" {
+			return ErrTransferForbidden
+		}
+		if options.TransferTo.IsZero() {
+			return fmt.Errorf("%w: transfer_to is required to transfer", ErrInvalidCascade)
+		}
+		if options.TransferTo == deletedUserID {
+			return fmt.Errorf("%w: cannot transfer to the deleted user", ErrInvalidCascade)
+		}
+	default:
+		return fmt.Errorf("%w mode %q", ErrInvalidCascade, options.Mode)
+	}
+	return nil
+}
+
+// cascadeDeletion applies the cascade mode to the reservations, holds and waitlist entries matching filter before
+// their food truck or user is deleted. Reservations of days past at their location are kept as history. In block
+// mode nothing is changed and ErrDeletionBlocked is returned while future reservations exist.
+func (s *ReservationService) cascadeDeletion(ctx context.Context, filter bson.M, options *CascadeOptions, reason string, report *DeletionReport) error {
+	// Locations behind UTC may still be on the previous day
+	now := time.Now()
+	future := bson.M{"$and": []bson.M{filter, {
+		"date":   bson.M{"$gte": utils.TruncateToDay(now).AddDate(0, 0, -1)},
+		"status": activeStatusFilter(),
+	}}}
+	cursor, err := s.ReservationCollection.Find(ctx, future)
+	if err != nil {
+		return fmt.Errorf("failed to fetch future reservations: %v", err)
+	}
+	var found []model.Reservation
+	if err := cursor.All(ctx, &found); err != nil {
+		return fmt.Errorf("failed to fetch future reservations: %v", err)
+	}
+	reservations := make([]model.Reservation, 0, len(found))
+	for _, reservation := range found {
+		today, err := s.todayAt(ctx, reservation.LocationID, now)
+		if err != nil {
+			return err
+		}
+		if !reservation.Date.Before(today) {
+			reservations = append(reservations, reservation)
+		}
+	}
+
+	if options.Mode == CascadeBlock {
+		if len(reservations) == 0 {
+			return nil
+		}
+		for _, reservation := range reservations {
+			report.BlockingReservations = append(report.BlockingReservations, reservation.ID)
+		}
+		return ErrDeletionBlocked
+	}
+
+	// Waiting for a spot follows the reservations. Entries are dropped before any spot is released so that they
+	// are not offered the spots being freed.
+	if options.Mode == CascadeTransfer {
+		if _, err := s.WaitlistCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"user_id": options.TransferTo}}); err != nil {
+			return fmt.Errorf("failed to transfer waitlist entries: %v", err)
+		}
+	} else {
+		result, err := s.WaitlistCollection.DeleteMany(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to remove waitlist entries: %v", err)
+		}
+		report.RemovedWaitlistEntries = int(result.DeletedCount)
+	}
+
+	// Holds belong to whoever placed them and are given back in every mode
+	for {
+		var hold model.ReservationHold
+		err := s.HoldCollection.FindOneAndDelete(ctx, filter).Decode(&hold)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to release holds: %v", err)
+		}
+		if err := s.releaseHeldSpot(ctx, &hold); err != nil {
+			return err
+		}
+		report.ReleasedHolds++
+	}
+
+	if options.Mode == CascadeCancel {
+		for _, reservation := range reservations {
+			if _, err := s.ChangeReservationStatus(ctx, reservation.ID, primitive.NilObjectID, options.ActorID, model.StatusCancelled, reason); err != nil {
+				return fmt.Errorf("failed to cancel reservation %s: %v", reservation.ID.Hex(), err)
+			}
+			report.CancelledReservations = append(report.CancelledReservations, reservation.ID)
+		}
+		return nil
+	}
+
+	transferred := make([]primitive.ObjectID, 0, len(reservations))
+	for _, reservation := range reservations {
+		transferred = append(transferred, reservation.ID)
+	}
+	if len(transferred) > 0 {
+		_, err := s.ReservationCollection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": transferred}}, bson.M{"$set": bson.M{"user_id": options.TransferTo}})
+		if err != nil {
+			return fmt.Errorf("failed to transfer reservations: %v", err)
+		}
+		report.TransferredReservations = append(report.TransferredReservations, transferred...)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+)
+
+func TestDefaultCascadeMode(t *testing.T) {
+	t.Setenv("DELETION_CASCADE", "")
+	if mode := DefaultCascadeMode(); mode != CascadeBlock {
+		t.Fatalf("expected deletions to be blocked by default, got %s", mode)
+	}
+	t.Setenv("DELETION_CASCADE", CascadeCancel)
+	if mode := DefaultCascadeMode(); mode != CascadeCancel {
+		t.Fatalf("expected the configured mode, got %s", mode)
+	}
+	t.Setenv("DELETION_CASCADE", "purge")
+	if mode := DefaultCascadeMode(); mode != CascadeBlock {
+		t.Fatalf("expected an invalid mode to fall back to block, got %s", mode)
+	}
+}
+
+func TestCheckCascadeOptions(t *testing.T) {
+	deletedUserID := primitive.NewObjectID()
+	transferTo := primitive.NewObjectID()
+
+	tests := []struct {
+		name     string
+		env      string
+		options  CascadeOptions
+		role     string
+		wantMode string
+		wantErr  error
+	}{
+		{"default mode", "", CascadeOptions{}, "user", CascadeBlock, nil},
+		{"configured default mode", CascadeCancel, CascadeOptions{}, "user", CascadeCancel, nil},
+		{"requested mode over the default", CascadeCancel, CascadeOptions{Mode: CascadeBlock}, "user", CascadeBlock, nil},
+		{"unknown mode", "", CascadeOptions{Mode: "purge"}, "admin", "purge", ErrInvalidCascade},
+		{"transfer by an admin", "", CascadeOptions{Mode: CascadeTransfer, TransferTo: transferTo}, "admin", CascadeTransfer, nil},
+		{"transfer by a user", "", CascadeOptions{Mode: CascadeTransfer, TransferTo: transferTo}, "user", CascadeTransfer, ErrTransferForbidden},
+		{"configured transfer by a user", CascadeTransfer, CascadeOptions{TransferTo: transferTo}, "user", CascadeTransfer, ErrTransferForbidden},
+		{"transfer without recipient", "", CascadeOptions{Mode: CascadeTransfer}, "admin", CascadeTransfer, ErrInvalidCascade},
+		{"transfer to the deleted user", "", CascadeOptions{Mode: CascadeTransfer, TransferTo: deletedUserID}, "admin", CascadeTransfer, ErrInvalidCascade},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DELETION_CASCADE", tt.env)
+			options := tt.options
+			err := checkCascadeOptions(&options, tt.role, deletedUserID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkCascadeOptions() error = %v, want %v", err, tt.wantErr)
+			}
+			if options.Mode != tt.wantMode {
+				t.Fatalf("checkCascadeOptions() mode = %s, want %s", options.Mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+// newTestFoodtruckService wires a FoodtruckService on the collections of a reservation service
+func newTestFoodtruckService(service *ReservationService) *FoodtruckService {
+	return &FoodtruckService{
+		FoodtruckCollection:   service.FoodtruckCollection,
+		ReservationCollection: service.ReservationCollection,
+		Reservations:          service,
+	}
+}
+
+// insertTestAdmin stores an admin allowed to transfer on deletions and returns its ID
+func insertTestAdmin(t *testing.T, service *ReservationService) primitive.ObjectID {
+	admin := model.User{ID: primitive.NewObjectID(), Role: "admin"}
+	if _, err := service.UserCollection.InsertOne(context.Background(), admin); err != nil {
+		t.Fatalf("failed to insert admin: %v", err)
+	}
+	return admin.ID
+}
+
+func TestDeleteFoodtruckBlockedByFutureReservations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	foodtrucks := newTestFoodtruckService(service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+
+	report, err := foodtrucks.DeleteFoodtruck(ctx, reservation.FoodTruckID, reservation.UserID, CascadeOptions{Mode: CascadeBlock})
+	if !errors.Is(err, ErrDeletionBlocked) {
+		t.Fatalf("expected the deletion to be blocked, got %v", err)
+	}
+	if report.Deleted || len(report.BlockingReservations) != 1 || report.BlockingReservations[0] != reservation.ID {
+		t.Fatalf("expected the reservation to be reported as blocking, got %+v", report)
+	}
+	if count, _ := service.FoodtruckCollection.CountDocuments(ctx, bson.M{"_id": reservation.FoodTruckID}); count != 1 {
+		t.Fatal("expected the food truck to be kept")
+	}
+}
+
+func TestDeleteFoodtruckCancelsFutureReservations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	foodtrucks := newTestFoodtruckService(service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+
+	report, err := foodtrucks.DeleteFoodtruck(ctx, reservation.FoodTruckID, reservation.UserID, CascadeOptions{Mode: CascadeCancel})
+	if err != nil {
+		t.Fatalf("failed to delete food truck: %v", err)
+	}
+	if !report.Deleted || len(report.CancelledReservations) != 1 {
+		t.Fatalf("expected the food truck to be deleted and its reservation cancelled, got %+v", report)
+	}
+
+	cancelled, err := service.GetReservationByID(ctx, reservation.ID, primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to fetch reservation: %v", err)
+	}
+	if reservationStatus(cancelled) != model.StatusCancelled {
+		t.Fatalf("expected the reservation to be cancelled, got %s", reservationStatus(cancelled))
+	}
+	occupancy, err := service.Occupancy.GetOccupancy(ctx, reservation.SpotID, reservation.Date, "")
+	if err != nil {
+		t.Fatalf("failed to read occupancy: %v", err)
+	}
+	if occupancy.ReservedCount != 0 {
+		t.Fatalf("expected the spot number to be released, got %+v", occupancy)
+	}
+}
+
+func TestDeleteUserTransfersFoodtrucksAndReservations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	foodtrucks := newTestFoodtruckService(service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+	users := &UserService{
+		UserCollection:      service.UserCollection,
+		FoodtruckCollection: foodtrucks.FoodtruckCollection,
+		Reservations:        service,
+	}
+
+	cascade := CascadeOptions{Mode: CascadeTransfer, TransferTo: primitive.NewObjectID(), ActorID: insertTestAdmin(t, service)}
+	if _, err := users.DeleteUser(ctx, reservation.UserID.Hex(), cascade); !errors.Is(err, ErrInvalidCascade) {
+		t.Fatalf("expected an unknown transfer target to be refused, got %v", err)
+	}
+
+	if _, err := service.UserCollection.InsertOne(ctx, model.User{ID: cascade.TransferTo, Role: "user"}); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	report, err := users.DeleteUser(ctx, reservation.UserID.Hex(), cascade)
+	if err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if !report.Deleted || len(report.TransferredFoodtrucks) != 1 || len(report.TransferredReservations) != 1 {
+		t.Fatalf("expected the food truck and its reservation to be transferred, got %+v", report)
+	}
+
+	var foodtruck model.Foodtruck
+	if err := foodtrucks.FoodtruckCollection.FindOne(ctx, bson.M{"_id": reservation.FoodTruckID}).Decode(&foodtruck); err != nil {
+		t.Fatalf("failed to fetch food truck: %v", err)
+	}
+	transferred, err := service.GetReservationByID(ctx, reservation.ID, primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to fetch reservation: %v", err)
+	}
+	if foodtruck.UserID != cascade.TransferTo || transferred.UserID != cascade.TransferTo {
+		t.Fatalf("expected the new owner to hold the food truck and reservation, got %s and %s", foodtruck.UserID.Hex(), transferred.UserID.Hex())
+	}
+	if err := service.UserCollection.FindOne(ctx, bson.M{"_id": reservation.UserID}).Err(); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("expected the user to be deleted, got %v", err)
+	}
+}
+
+func TestDeleteFoodtruckTransferRequiresAdmin(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	foodtrucks := newTestFoodtruckService(service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+	recipient := primitive.NewObjectID()
+	if _, err := service.UserCollection.InsertOne(ctx, model.User{ID: recipient, Role: "user"}); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	cascade := CascadeOptions{Mode: CascadeTransfer, TransferTo: recipient, ActorID: reservation.UserID}
+	if _, err := foodtrucks.DeleteFoodtruck(ctx, reservation.FoodTruckID, reservation.UserID, cascade); !errors.Is(err, ErrTransferForbidden) {
+		t.Fatalf("expected an owner to be refused a transfer, got %v", err)
+	}
+
+	cascade.ActorID = insertTestAdmin(t, service)
+	report, err := foodtrucks.AdminDeleteFoodtruck(ctx, reservation.FoodTruckID, cascade)
+	if err != nil {
+		t.Fatalf("failed to transfer food truck: %v", err)
+	}
+	if len(report.TransferredFoodtrucks) != 1 || len(report.TransferredReservations) != 1 {
+		t.Fatalf("expected the food truck and its reservation to be transferred, got %+v", report)
+	}
+}
+
+func TestDeleteUserReturnsStaffBookingsToOwners(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	foodtrucks := newTestFoodtruckService(service)
+	reservation := bookTestReservations(t, service, 1)[0]
+	ctx := context.Background()
+	users := &UserService{
+		UserCollection:      service.UserCollection,
+		FoodtruckCollection: foodtrucks.FoodtruckCollection,
+		Reservations:        service,
+	}
+
+	// The deleted user also books for another owner's food truck as staff
+	staffed := model.Foodtruck{ID: primitive.NewObjectID(), Name: "Staffed truck", UserID: primitive.NewObjectID(), StaffIDs: []primitive.ObjectID{reservation.UserID}}
+	if _, err := foodtrucks.FoodtruckCollection.InsertOne(ctx, staffed); err != nil {
+		t.Fatalf("failed to insert food truck: %v", err)
+	}
+	staffBooking := &model.Reservation{SpotID: reservation.SpotID, FoodTruckID: staffed.ID, UserID: reservation.UserID, SpotNumber: 2, Date: reservation.Date}
+	if err := service.CreateReservation(ctx, staffBooking); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+
+	cascade := CascadeOptions{Mode: CascadeTransfer, TransferTo: insertTestAdmin(t, service)}
+	cascade.ActorID = cascade.TransferTo
+	report, err := users.DeleteUser(ctx, reservation.UserID.Hex(), cascade)
+	if err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if len(report.TransferredReservations) != 2 {
+		t.Fatalf("expected both reservations to be transferred, got %+v", report)
+	}
+
+	expected := map[primitive.ObjectID]primitive.ObjectID{reservation.ID: cascade.TransferTo, staffBooking.ID: staffed.UserID}
+	for id, userID := range expected {
+		transferred, err := service.GetReservationByID(ctx, id, primitive.NilObjectID)
+		if err != nil {
+			t.Fatalf("failed to fetch reservation: %v", err)
+		}
+		if transferred.UserID != userID {
+			t.Fatalf("expected reservation %s to go to %s, got %s", id.Hex(), userID.Hex(), transferred.UserID.Hex())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+var (
+	// ErrFoodtruckNotFound is returned when booking for a food truck that does not exist
+	ErrFoodtruckNotFound = errors.New("food truck not found")
+	// ErrFoodtruckForbidden is returned when booking for a food truck the user neither owns nor staffs
+	ErrFoodtruckForbidden = errors.New("you cannot book for this food truck")
+	// ErrFoodtruckSuspended is returned when booking for a food truck an admin suspended
+	ErrFoodtruckSuspended = errors.New("food truck is suspended from booking")
+)
+
+// canBookFor reports whether a user may book on behalf of a food truck: its owner, its staff and admins can
+func canBookFor(foodtruck *model.Foodtruck, userID primitive.ObjectID, role string) bool {
+	if role == "admin" || foodtruck.UserID == userID {
+		return true
+	}
+	for _, staffID := range foodtruck.StaffIDs {
+		if staffID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFoodtruckStanding refuses food trucks suspended by an admin
+func checkFoodtruckStanding(foodtruck *model.Foodtruck, now time.Time) error {
+	if foodtruck.SuspendedUntil != nil && foodtruck.SuspendedUntil.After(now) {
+		return fmt.Errorf("%w until %s", ErrFoodtruckSuspended, foodtruck.SuspendedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// bookableFoodtruck returns the food truck of a booking once it is known to exist, to be bookable by the user and
+// to be in good standing
+func (s *ReservationService) bookableFoodtruck(ctx context.Context, foodTruckID, userID primitive.ObjectID) (*model.Foodtruck, error) {
+	foodtruck, err := findFoodtruck(ctx, s.FoodtruckCollection, foodTruckID)
+	if err != nil {
+		return nil, err
+	}
+	if foodtruck.ID.IsZero() {
+		return nil, ErrFoodtruckNotFound
+	}
+	if !canBookFor(foodtruck, userID, s.userRole(ctx, userID)) {
+		return nil, ErrFoodtruckForbidden
+	}
+	if err := checkFoodtruckStanding(foodtruck, time.Now()); err != nil {
+		return nil, err
+	}
+	return foodtruck, nil
+}
+
+// BackfillFoodtruckNames stores the food truck name on the reservations made before it was recorded
+func (s *ReservationService) BackfillFoodtruckNames(ctx context.Context) error {
+	missing := bson.M{"food_truck_name": bson.M{"$exists": false}}
+	ids, err := s.ReservationCollection.Distinct(ctx, "food_truck_id", missing)
+	if err != nil {
+		return fmt.Errorf("failed to list food trucks of reservations: %v", err)
+	}
+
+	for _, value := range ids {
+		foodTruckID, ok := value.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		foodtruck, err := findFoodtruck(ctx, s.FoodtruckCollection, foodTruckID)
+		if err != nil {
+			return err
+		}
+		if foodtruck.ID.IsZero() {
+			continue
+		}
+
+		filter := bson.M{"food_truck_id": foodTruckID, "food_truck_name": bson.M{"$exists": false}}
+		if _, err := s.ReservationCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"food_truck_name": foodtruck.Name}}); err != nil {
+			return fmt.Errorf("failed to store food truck names: %v", err)
+		}
+	}
+
+	return nil
+}
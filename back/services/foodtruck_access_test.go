@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestCanBookFor(t *testing.T) {
+	owner, staff, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	foodtruck := &model.Foodtruck{UserID: owner, StaffIDs: []primitive.ObjectID{staff}}
+
+	tests := []struct {
+		name   string
+		userID primitive.ObjectID
+		role   string
+		want   bool
+	}{
+		{"owner", owner, "user", true},
+		{"staff", staff, "user", true},
+		{"admin", stranger, "admin", true},
+		{"stranger", stranger, "user", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canBookFor(foodtruck, tt.userID, tt.role); got != tt.want {
+				t.Fatalf("canBookFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckFoodtruckStanding(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	if err := checkFoodtruckStanding(&model.Foodtruck{}, now); err != nil {
+		t.Fatalf("expected a truck never suspended to be in good standing: %v", err)
+	}
+	if err := checkFoodtruckStanding(&model.Foodtruck{SuspendedUntil: &past}, now); err != nil {
+		t.Fatalf("expected an expired suspension to be ignored: %v", err)
+	}
+	if err := checkFoodtruckStanding(&model.Foodtruck{SuspendedUntil: &future}, now); !errors.Is(err, ErrFoodtruckSuspended) {
+		t.Fatalf("expected a suspended truck to be refused, got %v", err)
+	}
+}
@@ -1,25 +1,31 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"gitlab.com/hooly2/back/db"
 	"gitlab.com/hooly2/back/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/net/context"
 	"time"
 )
 
 // FoodtruckService provides CRUD operations for Foodtruck
 type FoodtruckService struct {
-	FoodtruckCollection *mongo.Collection
+	FoodtruckCollection   *mongo.Collection
+	ReservationCollection *mongo.Collection
+	Reservations          *ReservationService // Cascades deletions to reservations
 }
 
 // NewFoodtruckService creates a new FoodtruckService
 func NewFoodtruckService() *FoodtruckService {
 	return &FoodtruckService{
-		FoodtruckCollection: db.GetCollection("foodtruck"),
+		FoodtruckCollection:   db.GetCollection("foodtruck"),
+		ReservationCollection: db.GetCollection("reservation"),
+		Reservations:          NewReservationService(),
 	}
 }
 
@@ -74,13 +80,52 @@ func (s *FoodtruckService) GetFoodTruckByID(ctx context.Context, foodtruckId pri
 	return &foodtruck, nil
 }
 
+// GetAttendance retrieves the attendance counters and past reservations of a food truck, optionally scoped by user ID.
+func (s *FoodtruckService) GetAttendance(ctx context.Context, foodtruckID primitive.ObjectID, userID primitive.ObjectID) (*model.Attendance, error) {
+	foodtruck, err := s.GetFoodTruckByID(ctx, foodtruckID, userID)
+	if err != nil {
+		return nil, errors.New("foodtruck not found")
+	}
+
+	filter := bson.M{
+		"food_truck_id": foodtruck.ID,
+		"date":          bson.M{"$lt": time.Now()},
+	}
+	cursor, err := s.ReservationCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []model.Reservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+
+	return &model.Attendance{
+		FoodTruckID:   foodtruck.ID,
+		AttendedCount: foodtruck.AttendedCount,
+		NoShowCount:   foodtruck.NoShowCount,
+		Reservations:  reservations,
+	}, nil
+}
+
 // AddFoodtruck Add a foodtruck
 func (s *FoodtruckService) AddFoodtruck(foodtruck *model.Foodtruck) (*model.Foodtruck, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Assign a unique ID to the food truck
+	if err := validateRequirements(foodtruck.Requirements); err != nil {
+		return nil, err
+	}
+	if err := validatePreferredSpots(foodtruck.PreferredSpots); err != nil {
+		return nil, err
+	}
+
+	// Assign a unique ID to the food truck and start with a clean attendance record
 	foodtruck.ID = primitive.NewObjectID()
+	foodtruck.AttendedCount = 0
+	foodtruck.NoShowCount = 0
 
 	// Insert the food truck into the MongoDB collection
 	_, err := s.FoodtruckCollection.InsertOne(ctx, foodtruck)
@@ -98,6 +143,43 @@ func (s *FoodtruckService) UpdateFoodtruck(ctx context.Context, foodTruckID prim
 		filter["user_id"] = userID
 	}
 
+	// Attendance counters are maintained by check-ins only, and suspensions by admins
+	delete(updateData, "attended_count")
+	delete(updateData, "no_show_count")
+	delete(updateData, "suspended_until")
+
+	// Store the requirements with their proper types
+	if raw, ok := updateData["requirements"]; ok {
+		var requirements model.TruckRequirements
+		data, err := json.Marshal(raw)
+		if err != nil || json.Unmarshal(data, &requirements) != nil {
+			return errors.New("invalid requirements")
+		}
+		if err := validateRequirements(requirements); err != nil {
+			return err
+		}
+		updateData["requirements"] = requirements
+	}
+	if raw, ok := updateData["preferred_spots"]; ok {
+		var preferred []int
+		data, err := json.Marshal(raw)
+		if err != nil || json.Unmarshal(data, &preferred) != nil {
+			return errors.New("invalid preferred_spots")
+		}
+		if err := validatePreferredSpots(preferred); err != nil {
+			return err
+		}
+		updateData["preferred_spots"] = preferred
+	}
+	if raw, ok := updateData["staff_ids"]; ok {
+		var staffIDs []primitive.ObjectID
+		data, err := json.Marshal(raw)
+		if err != nil || json.Unmarshal(data, &staffIDs) != nil {
+			return errors.New("invalid staff_ids")
+		}
+		updateData["staff_ids"] = staffIDs
+	}
+
 	update := bson.M{"$set": updateData}
 	_, err := s.FoodtruckCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -106,29 +188,68 @@ func (s *FoodtruckService) UpdateFoodtruck(ctx context.Context, foodTruckID prim
 	return nil
 }
 
-// DeleteFoodtruck deletes a reservation by ID, optionally scoped by user ID.
-func (s *FoodtruckService) DeleteFoodtruck(ctx context.Context, foodtruckID primitive.ObjectID, userID primitive.ObjectID) error {
-	filter := bson.M{"_id": foodtruckID}
-	if !userID.IsZero() {
-		filter["user_id"] = userID
+// SuspendFoodtruck keeps a food truck from booking until a date, or lifts its suspension when until is nil
+func (s *FoodtruckService) SuspendFoodtruck(ctx context.Context, foodtruckID primitive.ObjectID, until *time.Time) error {
+	update := bson.M{"$unset": bson.M{"suspended_until": ""}}
+	if until != nil {
+		update = bson.M{"$set": bson.M{"suspended_until": *until}}
 	}
 
-	_, err := s.FoodtruckCollection.DeleteOne(ctx, filter)
+	result, err := s.FoodtruckCollection.UpdateOne(ctx, bson.M{"_id": foodtruckID}, update)
 	if err != nil {
-		return errors.New("failed to delete reservation or not authorized")
+		return errors.New("failed to update foodtruck")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("foodtruck not found")
 	}
-
 	return nil
 }
 
-// AdminDeleteFoodtruck deletes a reservation without user_id restrictions (admin functionality).
-func (s *FoodtruckService) AdminDeleteFoodtruck(ctx context.Context, foodtruckID primitive.ObjectID) error {
+// DeleteFoodtruck deletes a foodtruck by ID, optionally scoped by user ID. Its future reservations, holds and
+// waitlist entries are handled according to the cascade mode; in transfer mode the foodtruck is given to another
+// user instead of being deleted.
+func (s *FoodtruckService) DeleteFoodtruck(ctx context.Context, foodtruckID primitive.ObjectID, userID primitive.ObjectID, cascade CascadeOptions) (*DeletionReport, error) {
 	filter := bson.M{"_id": foodtruckID}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	var foodtruck model.Foodtruck
+	if err := s.FoodtruckCollection.FindOne(ctx, filter).Decode(&foodtruck); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrFoodtruckNotFound
+		}
+		return nil, err
+	}
 
-	_, err := s.FoodtruckCollection.DeleteOne(ctx, filter)
+	report, err := s.Reservations.newDeletionReport(ctx, &cascade, foodtruck.UserID)
 	if err != nil {
-		return errors.New("failed to delete reservation")
+		return nil, err
 	}
 
-	return nil
+	err = s.Reservations.cascadeDeletion(ctx, bson.M{"food_truck_id": foodtruckID}, &cascade, "Food truck deleted", report)
+	if err != nil {
+		return report, err
+	}
+
+	if cascade.Mode == CascadeTransfer {
+		if _, err := s.FoodtruckCollection.UpdateOne(ctx, bson.M{"_id": foodtruckID}, bson.M{"$set": bson.M{"user_id": cascade.TransferTo}}); err != nil {
+			return report, errors.New("failed to transfer foodtruck")
+		}
+		report.TransferredFoodtrucks = append(report.TransferredFoodtrucks, foodtruckID)
+		return report, nil
+	}
+
+	if _, err := s.FoodtruckCollection.DeleteOne(ctx, bson.M{"_id": foodtruckID}); err != nil {
+		return report, errors.New("failed to delete foodtruck")
+	}
+	report.Deleted = true
+	report.DeletedFoodtrucks = append(report.DeletedFoodtrucks, foodtruckID)
+
+	return report, nil
+}
+
+// AdminDeleteFoodtruck deletes a foodtruck without user_id restrictions (admin functionality).
+func (s *FoodtruckService) AdminDeleteFoodtruck(ctx context.Context, foodtruckID primitive.ObjectID, cascade CascadeOptions) (*DeletionReport, error) {
+	return s.DeleteFoodtruck(ctx, foodtruckID, primitive.NilObjectID, cascade)
 }
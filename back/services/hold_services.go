@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	// defaultHoldMinutes is how long a hold lasts unless RESERVATION_HOLD_MINUTES says otherwise
+	defaultHoldMinutes = 5
+	// maxHoldsPerUser bounds the holds a user keeps at once so that spots cannot be hoarded
+	maxHoldsPerUser = 3
+)
+
+// ErrHoldNotFound is returned when a hold does not exist, expired or belongs to another user
+var ErrHoldNotFound = errors.New("hold not found or expired")
+
+// holdDuration reads how long holds last from the RESERVATION_HOLD_MINUTES environment variable
+func holdDuration() time.Duration {
+	minutes := envInt("RESERVATION_HOLD_MINUTES", defaultHoldMinutes)
+	if minutes == 0 {
+		minutes = defaultHoldMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// CreateHold keeps a spot number for the user while they finish booking. The hold passes the same checks as a
+// reservation and claims the spot number right away, so other users see it as taken until it expires.
+func (s *ReservationService) CreateHold(ctx context.Context, hold *model.ReservationHold) error {
+	if hold.SpotNumber <= 0 {
+		return errors.New("spot_number is required")
+	}
+	hold.Date = utils.TruncateToDay(hold.Date)
+
+	// Spot numbers of expired holds may be what the user is after
+	if _, err := s.ReleaseExpiredHolds(ctx); err != nil {
+		return err
+	}
+
+	count, err := s.HoldCollection.CountDocuments(ctx, bson.M{"user_id": hold.UserID, "expires_at": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to check holds: %v", err)
+	}
+	if count >= maxHoldsPerUser {
+		return fmt.Errorf("cannot hold more than %d spots at once", maxHoldsPerUser)
+	}
+
+	candidate := &model.Reservation{
+		SpotID:      hold.SpotID,
+		FoodTruckID: hold.FoodTruckID,
+		UserID:      hold.UserID,
+		SpotNumber:  hold.SpotNumber,
+		SlotID:      hold.SlotID,
+		Date:        hold.Date,
+	}
+	violations, err := s.reservationViolations(ctx, candidate)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return violations[0]
+	}
+
+	var parkingSpot model.ParkingSpot
+	if err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": hold.SpotID}).Decode(&parkingSpot); err != nil {
+		return err
+	}
+	slot, err := resolveSlot(&parkingSpot, hold.SlotID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Occupancy.Reserve(ctx, parkingSpot.ID, hold.Date, hold.SlotID, hold.SpotNumber, slotCapacity(&parkingSpot, slot)); err != nil {
+		return err
+	}
+
+	hold.ID = primitive.NewObjectID()
+	hold.LocationID = parkingSpot.LocationID
+	hold.CreatedAt = time.Now()
+	hold.ExpiresAt = hold.CreatedAt.Add(holdDuration())
+	if _, err := s.HoldCollection.InsertOne(ctx, hold); err != nil {
+		_ = s.Occupancy.Release(ctx, parkingSpot.ID, hold.Date, hold.SlotID, hold.SpotNumber)
+		return fmt.Errorf("failed to create hold: %v", err)
+	}
+
+	return nil
+}
+
+// GetUserHolds lists the holds of a user that have not expired
+func (s *ReservationService) GetUserHolds(ctx context.Context, userID primitive.ObjectID) ([]model.ReservationHold, error) {
+	cursor, err := s.HoldCollection.Find(ctx, bson.M{"user_id": userID, "expires_at": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	holds := []model.ReservationHold{}
+	if err = cursor.All(ctx, &holds); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// ReleaseHold gives the spot number of one of the user's holds back before it expires
+func (s *ReservationService) ReleaseHold(ctx context.Context, holdID, userID primitive.ObjectID) error {
+	var hold model.ReservationHold
+	err := s.HoldCollection.FindOneAndDelete(ctx, bson.M{"_id": holdID, "user_id": userID}).Decode(&hold)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrHoldNotFound
+		}
+		return err
+	}
+
+	return s.releaseHeldSpot(ctx, &hold)
+}
+
+// ReleaseExpiredHolds gives back the spot numbers of the holds that expired and returns how many there were.
+// Each hold is deleted before its spot number is released so that a concurrent sweep cannot release it twice.
+func (s *ReservationService) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		var hold model.ReservationHold
+		err := s.HoldCollection.FindOneAndDelete(ctx, bson.M{"expires_at": bson.M{"$lte": time.Now()}}).Decode(&hold)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return released, nil
+		}
+		if err != nil {
+			return released, fmt.Errorf("failed to fetch expired holds: %v", err)
+		}
+
+		if err := s.releaseHeldSpot(ctx, &hold); err != nil {
+			return released, err
+		}
+		released++
+	}
+}
+
+// RunHoldSweeper releases expired holds at every interval until ctx is done. Holds are expired by this sweeper
+// rather than a TTL index, which would delete them without giving their spot number back.
+func (s *ReservationService) RunHoldSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if released, err := s.ReleaseExpiredHolds(ctx); err != nil {
+			log.Println("Error sweeping holds:", err)
+		} else if released > 0 {
+			log.Printf("Released %d expired hold(s)", released)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnsureHoldIndexes creates the indexes used to sweep expired holds and list the holds of a user
+func (s *ReservationService) EnsureHoldIndexes(ctx context.Context) error {
+	_, err := s.HoldCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "spot_id", Value: 1}, {Key: "date", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create hold indexes: %v", err)
+	}
+	return nil
+}
+
+// holdFilter matches the live hold of the user for the food truck, spot, date and slot of a reservation, on its spot
+// number unless the reservation is made without one
+func holdFilter(reservation *model.Reservation) bson.M {
+	filter := bson.M{
+		"user_id":       reservation.UserID,
+		"food_truck_id": reservation.FoodTruckID,
+		"spot_id":       reservation.SpotID,
+		"date":          utils.TruncateToDay(reservation.Date),
+		"slot_id":       reservation.SlotID,
+		"expires_at":    bson.M{"$gt": time.Now()},
+	}
+	if reservation.SpotNumber != 0 {
+		filter["spot_number"] = reservation.SpotNumber
+	}
+	return filter
+}
+
+// activeHold returns the live hold of the user matching a reservation, nil when there is none
+func (s *ReservationService) activeHold(ctx context.Context, reservation *model.Reservation) (*model.ReservationHold, error) {
+	var hold model.ReservationHold
+	err := s.HoldCollection.FindOne(ctx, holdFilter(reservation)).Decode(&hold)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check holds: %v", err)
+	}
+	return &hold, nil
+}
+
+// consumeHold turns the live hold of the user matching a reservation into that reservation: the hold is deleted
+// and its spot number, already claimed, is given to the reservation. It returns false when there is no such hold.
+func (s *ReservationService) consumeHold(ctx context.Context, reservation *model.Reservation) (bool, error) {
+	var hold model.ReservationHold
+	err := s.HoldCollection.FindOneAndDelete(ctx, holdFilter(reservation)).Decode(&hold)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check holds: %v", err)
+	}
+
+	reservation.SpotNumber = hold.SpotNumber
+	return true, nil
+}
+
+// releaseHeldSpot frees the spot number of a deleted hold and offers it to whoever waits for it
+func (s *ReservationService) releaseHeldSpot(ctx context.Context, hold *model.ReservationHold) error {
+	if err := s.Occupancy.Release(ctx, hold.SpotID, hold.Date, hold.SlotID, hold.SpotNumber); err != nil {
+		return err
+	}
+	s.notifyRelease(ctx, hold.SpotID, hold.Date, hold.SlotID, hold.SpotNumber)
+	return nil
+}
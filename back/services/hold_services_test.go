@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestHoldIsConvertedIntoReservationByItsHolder(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2})
+
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	hold := &model.ReservationHold{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 2, Date: date}
+	if err := service.CreateHold(ctx, hold); err != nil {
+		t.Fatalf("failed to hold spot: %v", err)
+	}
+
+	// The held spot number is taken for everyone else
+	otherTruckID, otherUserID := insertTestFoodtruck(t, service)
+	err := service.CreateReservation(ctx, &model.Reservation{SpotID: spotID, FoodTruckID: otherTruckID, UserID: otherUserID, SpotNumber: 2, Date: date})
+	if !errors.Is(err, ErrSpotConflict) {
+		t.Fatalf("expected a conflict on a held spot number, got %v", err)
+	}
+
+	reservation := &model.Reservation{SpotID: spotID, FoodTruckID: hold.FoodTruckID, UserID: hold.UserID, SpotNumber: 2, Date: date}
+	if err := service.CreateReservation(ctx, reservation); err != nil {
+		t.Fatalf("expected the holder to book the held spot number: %v", err)
+	}
+
+	occupancy, err := service.Occupancy.GetOccupancy(ctx, spotID, date, "")
+	if err != nil {
+		t.Fatalf("failed to fetch occupancy: %v", err)
+	}
+	if occupancy.ReservedCount != 1 {
+		t.Fatalf("expected the hold to turn into the reservation without counting twice, got %d", occupancy.ReservedCount)
+	}
+	if count, _ := service.HoldCollection.CountDocuments(ctx, bson.M{}); count != 0 {
+		t.Fatalf("expected the hold to be consumed, %d left", count)
+	}
+}
+
+func TestHoldIsOnlyConvertedForItsFoodTruck(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2})
+
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	hold := &model.ReservationHold{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 2, Date: date}
+	if err := service.CreateHold(ctx, hold); err != nil {
+		t.Fatalf("failed to hold spot: %v", err)
+	}
+
+	// A second food truck of the holder cannot use the hold
+	otherTruck := model.Foodtruck{ID: primitive.NewObjectID(), Name: "Other truck", UserID: userID}
+	if _, err := service.FoodtruckCollection.InsertOne(ctx, otherTruck); err != nil {
+		t.Fatalf("failed to insert food truck: %v", err)
+	}
+	err := service.CreateReservation(ctx, &model.Reservation{SpotID: spotID, FoodTruckID: otherTruck.ID, UserID: userID, SpotNumber: 2, Date: date})
+	if !errors.Is(err, ErrSpotConflict) {
+		t.Fatalf("expected a conflict for another food truck of the holder, got %v", err)
+	}
+	if count, _ := service.HoldCollection.CountDocuments(ctx, bson.M{"_id": hold.ID}); count != 1 {
+		t.Fatal("expected the hold to be kept")
+	}
+}
+
+func TestExpiredHoldsAreReleased(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1})
+
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	hold := &model.ReservationHold{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 1, Date: date}
+	if err := service.CreateHold(ctx, hold); err != nil {
+		t.Fatalf("failed to hold spot: %v", err)
+	}
+	if _, err := service.HoldCollection.UpdateOne(ctx, bson.M{"_id": hold.ID}, bson.M{"$set": bson.M{"expires_at": time.Now().Add(-time.Second)}}); err != nil {
+		t.Fatalf("failed to expire hold: %v", err)
+	}
+
+	released, err := service.ReleaseExpiredHolds(ctx)
+	if err != nil || released != 1 {
+		t.Fatalf("expected one expired hold to be released, got %d, %v", released, err)
+	}
+
+	otherTruckID, otherUserID := insertTestFoodtruck(t, service)
+	if err := service.CreateReservation(ctx, &model.Reservation{SpotID: spotID, FoodTruckID: otherTruckID, UserID: otherUserID, SpotNumber: 1, Date: date}); err != nil {
+		t.Fatalf("expected the spot number of the expired hold to be bookable: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/middleware"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// IdempotencyTTL is how long responses are kept for retries made with the same Idempotency-Key
+const IdempotencyTTL = 24 * time.Hour
+
+// idempotencyDocument is how an IdempotencyRecord is stored
+type idempotencyDocument struct {
+	UserID      string    `bson:"user_id"`
+	Key         string    `bson:"key"`
+	RequestHash string    `bson:"request_hash"`
+	Completed   bool      `bson:"completed"`
+	Status      int       `bson:"status,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// IdempotencyService is the MongoDB store of the idempotency middleware
+type IdempotencyService struct {
+	IdempotencyCollection *mongo.Collection
+}
+
+func NewIdempotencyService() *IdempotencyService {
+	return &IdempotencyService{
+		IdempotencyCollection: db.GetCollection("idempotencyKey"),
+	}
+}
+
+// EnsureIndexes creates the unique index claiming a key once per user and the TTL index expiring the records
+func (s *IdempotencyService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.IdempotencyCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency indexes: %v", err)
+	}
+	return nil
+}
+
+// Begin claims a key by inserting its record; the unique index makes concurrent retries find the first one
+func (s *IdempotencyService) Begin(ctx context.Context, userID, key, requestHash string, ttl time.Duration) (*middleware.IdempotencyRecord, error) {
+	filter := bson.M{"user_id": userID, "key": key}
+
+	// The TTL monitor runs once a minute, so records may outlive their expiry a little
+	if _, err := s.IdempotencyCollection.DeleteOne(ctx, bson.M{"user_id": userID, "key": key, "expires_at": bson.M{"$lte": time.Now()}}); err != nil {
+		return nil, err
+	}
+
+	_, err := s.IdempotencyCollection.InsertOne(ctx, idempotencyDocument{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var existing idempotencyDocument
+	if err := s.IdempotencyCollection.FindOne(ctx, filter).Decode(&existing); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("idempotency key was freed concurrently")
+		}
+		return nil, err
+	}
+
+	return &middleware.IdempotencyRecord{
+		RequestHash: existing.RequestHash,
+		Completed:   existing.Completed,
+		Status:      existing.Status,
+		ContentType: existing.ContentType,
+		Body:        existing.Body,
+	}, nil
+}
+
+// Complete stores the response of the request that claimed a key
+func (s *IdempotencyService) Complete(ctx context.Context, userID, key string, record *middleware.IdempotencyRecord) error {
+	_, err := s.IdempotencyCollection.UpdateOne(ctx, bson.M{"user_id": userID, "key": key}, bson.M{"$set": bson.M{
+		"completed":    true,
+		"status":       record.Status,
+		"content_type": record.ContentType,
+		"body":         record.Body,
+	}})
+	return err
+}
+
+// Abandon deletes the record of a key so that the request can be retried
+func (s *IdempotencyService) Abandon(ctx context.Context, userID, key string) error {
+	_, err := s.IdempotencyCollection.DeleteOne(ctx, bson.M{"user_id": userID, "key": key})
+	return err
+}
@@ -0,0 +1,18 @@
+package services
+
+import "context"
+
+// EnsureIndexes creates the indexes the services rely on for consistency
+func EnsureIndexes(ctx context.Context) error {
+	if err := NewOccupancyService().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := NewReservationService().EnsureHoldIndexes(ctx); err != nil {
+		return err
+	}
+	if err := NewIdempotencyService().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+	"time"
+	_ "time/tzdata" // The runtime image ships without a timezone database
+)
+
+var (
+	// ErrLocationNotFound is returned when a location, or the default location, does not exist
+	ErrLocationNotFound = errors.New("location not found")
+	// ErrLocationForbidden is returned when an admin restricted to other locations tries to manage a location
+	ErrLocationForbidden = errors.New("you cannot manage this location")
+)
+
+// LocationService manages the sites owning parking spots and which admins may manage them
+type LocationService struct {
+	LocationCollection    *mongo.Collection
+	ParkingSpotCollection *mongo.Collection
+	UserCollection        *mongo.Collection
+}
+
+func NewLocationService() *LocationService {
+	return &LocationService{
+		LocationCollection:    db.GetCollection("location"),
+		ParkingSpotCollection: db.GetCollection("parkingSpot"),
+		UserCollection:        db.GetCollection("user"),
+	}
+}
+
+// CreateLocation validates and stores a new location; the first location becomes the default one
+func (s *LocationService) CreateLocation(ctx context.Context, location *model.Location) error {
+	if err := validateLocation(location); err != nil {
+		return err
+	}
+
+	count, err := s.LocationCollection.CountDocuments(ctx, bson.M{"is_default": true})
+	if err != nil {
+		return fmt.Errorf("failed to check locations: %v", err)
+	}
+
+	location.ID = primitive.NewObjectID()
+	location.IsDefault = count == 0
+	location.CreatedAt = time.Now()
+	if _, err := s.LocationCollection.InsertOne(ctx, location); err != nil {
+		return fmt.Errorf("failed to create location: %v", err)
+	}
+
+	return nil
+}
+
+// GetLocations retrieves every location
+func (s *LocationService) GetLocations(ctx context.Context) ([]model.Location, error) {
+	cursor, err := s.LocationCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch locations: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	locations := []model.Location{}
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, fmt.Errorf("failed to decode locations: %v", err)
+	}
+
+	return locations, nil
+}
+
+// GetLocation retrieves a location by ID, or the default location when the ID is zero
+func (s *LocationService) GetLocation(ctx context.Context, locationID primitive.ObjectID) (*model.Location, error) {
+	filter := bson.M{"_id": locationID}
+	if locationID.IsZero() {
+		filter = bson.M{"is_default": true}
+	}
+
+	var location model.Location
+	if err := s.LocationCollection.FindOne(ctx, filter).Decode(&location); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+// UpdateLocation replaces the name, address, timezone, opening days and approval settings of a location
+func (s *LocationService) UpdateLocation(ctx context.Context, locationID primitive.ObjectID, location *model.Location) error {
+	if err := validateLocation(location); err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"name":              location.Name,
+		"address":           location.Address,
+		"timezone":          location.Timezone,
+		"opening_days":      location.OpeningDays,
+		"requires_approval": location.RequiresApproval,
+		"approval_hours":    location.ApprovalHours,
+	}}
+	err := s.LocationCollection.FindOneAndUpdate(ctx, bson.M{"_id": locationID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(location)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrLocationNotFound
+		}
+		return fmt.Errorf("failed to update location: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteLocation removes a location that no longer owns parking spots
+func (s *LocationService) DeleteLocation(ctx context.Context, locationID primitive.ObjectID) error {
+	location, err := s.GetLocation(ctx, locationID)
+	if err != nil {
+		return err
+	}
+	if location.IsDefault {
+		return errors.New("the default location cannot be deleted")
+	}
+
+	spots, err := s.ParkingSpotCollection.CountDocuments(ctx, bson.M{"location_id": locationID})
+	if err != nil {
+		return fmt.Errorf("failed to check parking spots: %v", err)
+	}
+	if spots > 0 {
+		return errors.New("location still has parking spots")
+	}
+
+	if _, err := s.LocationCollection.DeleteOne(ctx, bson.M{"_id": locationID}); err != nil {
+		return fmt.Errorf("failed to delete location: %v", err)
+	}
+
+	return nil
+}
+
+// ManagedLocations returns the locations an admin is restricted to, empty when the admin manages every location
+func (s *LocationService) ManagedLocations(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var user model.User
+	if err := s.UserCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return user.ManagedLocations, nil
+}
+
+// CheckManager returns ErrLocationForbidden when the admin is restricted to other locations.
+// A zero location stands for every location and is only allowed to unrestricted admins.
+func (s *LocationService) CheckManager(ctx context.Context, userID primitive.ObjectID, locationID primitive.ObjectID) error {
+	managed, err := s.ManagedLocations(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(managed) == 0 {
+		return nil
+	}
+
+	for _, id := range managed {
+		if id == locationID {
+			return nil
+		}
+	}
+
+	return ErrLocationForbidden
+}
+
+// SetManagedLocations restricts an admin to the given locations, or lifts the restriction when none are given
+func (s *LocationService) SetManagedLocations(ctx context.Context, userID primitive.ObjectID, locationIDs []primitive.ObjectID) error {
+	if len(locationIDs) > 0 {
+		count, err := s.LocationCollection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": locationIDs}})
+		if err != nil {
+			return fmt.Errorf("failed to check locations: %v", err)
+		}
+		if int(count) != len(locationIDs) {
+			return errors.New("unknown location")
+		}
+	}
+
+	update := bson.M{"$set": bson.M{"managed_locations": locationIDs}}
+	if len(locationIDs) == 0 {
+		update = bson.M{"$unset": bson.M{"managed_locations": ""}}
+	}
+
+	result, err := s.UserCollection.UpdateOne(ctx, bson.M{"_id": userID, "role": "admin"}, update)
+	if err != nil {
+		return errors.New("failed to update managed locations")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("admin not found")
+	}
+
+	return nil
+}
+
+// MigrateDefaultLocation creates the default location on first start, open on the weekdays that already have
+// parking spots (every day on a fresh install), and attaches to it every parking spot, reservation, series and waitlist entry without a location.
+func (s *LocationService) MigrateDefaultLocation(ctx context.Context) error {
+	location, err := s.GetLocation(ctx, primitive.NilObjectID)
+	if err != nil {
+		if !errors.Is(err, ErrLocationNotFound) {
+			return err
+		}
+
+		days, err := s.ParkingSpotCollection.Distinct(ctx, "day_of_week", bson.M{})
+		if err != nil {
+			return fmt.Errorf("failed to list parking spot days: %v", err)
+		}
+		open := make(map[string]bool, len(days))
+		for _, value := range days {
+			if name, ok := value.(string); ok {
+				open[name] = true
+			}
+		}
+
+		// A fresh install opens every day
+		openingDays := []string{}
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if len(open) == 0 || open[day.String()] {
+				openingDays = append(openingDays, day.String())
+			}
+		}
+
+		timezone := os.Getenv("DEFAULT_LOCATION_TIMEZONE")
+		if timezone == "" {
+			timezone = "UTC"
+		}
+
+		location = &model.Location{Name: "Main lot", Timezone: timezone, OpeningDays: openingDays}
+		if err := s.CreateLocation(ctx, location); err != nil {
+			return err
+		}
+		log.Println("Created default location", location.ID.Hex())
+	}
+
+	missing := bson.M{"location_id": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"location_id": location.ID}}
+	for _, name := range []string{"parkingSpot", "reservation", "reservationSeries", "waitlist"} {
+		if _, err := db.GetCollection(name).UpdateMany(ctx, missing, update); err != nil {
+			return fmt.Errorf("failed to migrate %s to the default location: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateLocation checks the name, timezone and opening days of a location
+func validateLocation(location *model.Location) error {
+	if location.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if location.ApprovalHours < 0 {
+		return errors.New("approval_hours cannot be negative")
+	}
+
+	if location.Timezone == "" {
+		location.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(location.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", location.Timezone)
+	}
+
+	if location.OpeningDays == nil {
+		location.OpeningDays = []string{}
+	}
+	if location.Spots == nil {
+		location.Spots = []model.SpotAttributes{}
+	}
+	if err := validateSpotAttributes(location.Spots); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(location.OpeningDays))
+	for _, day := range location.OpeningDays {
+		if !utils.IsValidDayOfWeek(day) {
+			return fmt.Errorf("invalid opening day %q", day)
+		}
+		if seen[day] {
+			return fmt.Errorf("duplicate opening day %q", day)
+		}
+		seen[day] = true
+	}
+
+	return nil
+}
+
+// isOpeningDay reports whether a location opens on the weekday of date; a location without opening days never opens
+func isOpeningDay(location *model.Location, date time.Time) bool {
+	for _, day := range location.OpeningDays {
+		if day == date.Weekday().String() {
+			return true
+		}
+	}
+	return false
+}
+
+// locationTimezone returns the timezone of a location, UTC when the location or its timezone is missing or unknown
+func locationTimezone(location *model.Location) *time.Location {
+	if location == nil || location.Timezone == "" {
+		return time.UTC
+	}
+	timezone, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return timezone
+}
+
+// locationToday returns the calendar day it is at a location at the given instant, at midnight UTC like the dates
+// of reservations
+func locationToday(location *model.Location, now time.Time) time.Time {
+	local := now.In(locationTimezone(location))
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayStart returns the instant a reservation date starts at a location
+func dayStart(location *model.Location, date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, locationTimezone(location))
+}
+
+// todayAt returns the calendar day it is at a location at the given instant, the default location when the ID is zero
+func (s *ReservationService) todayAt(ctx context.Context, locationID primitive.ObjectID, now time.Time) (time.Time, error) {
+	location, err := s.Locations.GetLocation(ctx, locationID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return locationToday(location, now), nil
+}
+
+// UpdateSpotAttributes replaces the description of the spot numbers of a location
+func (s *LocationService) UpdateSpotAttributes(ctx context.Context, locationID primitive.ObjectID, spots []model.SpotAttributes) error {
+	if err := validateSpotAttributes(spots); err != nil {
+		return err
+	}
+	if spots == nil {
+		spots = []model.SpotAttributes{}
+	}
+
+	result, err := s.LocationCollection.UpdateOne(ctx, bson.M{"_id": locationID}, bson.M{"$set": bson.M{"spots": spots}})
+	if err != nil {
+		return fmt.Errorf("failed to update spot attributes: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrLocationNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"gitlab.com/hooly2/back/model"
+	"testing"
+	"time"
+)
+
+func TestValidateLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		location model.Location
+		wantErr  bool
+	}{
+		{"valid", model.Location{Name: "North park", Timezone: "Europe/Paris", OpeningDays: []string{"Monday", "Friday"}}, false},
+		{"timezone defaults to UTC", model.Location{Name: "North park"}, false},
+		{"missing name", model.Location{Timezone: "UTC"}, true},
+		{"unknown timezone", model.Location{Name: "North park", Timezone: "Mars/Olympus"}, true},
+		{"invalid opening day", model.Location{Name: "North park", OpeningDays: []string{"Funday"}}, true},
+		{"duplicate opening day", model.Location{Name: "North park", OpeningDays: []string{"Monday", "Monday"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := tt.location
+			err := validateLocation(&location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateLocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && location.Timezone == "" {
+				t.Fatal("expected the timezone to be set")
+			}
+		})
+	}
+}
+
+func TestIsOpeningDay(t *testing.T) {
+	location := &model.Location{OpeningDays: []string{"Monday", "Friday"}}
+	friday := time.Date(2025, time.May, 9, 0, 0, 0, 0, time.UTC)
+
+	if !isOpeningDay(location, friday) {
+		t.Fatal("expected the location to open on Friday")
+	}
+	if isOpeningDay(location, friday.AddDate(0, 0, 1)) {
+		t.Fatal("expected the location to be closed on Saturday")
+	}
+}
+
+func TestLocationToday(t *testing.T) {
+	now := time.Date(2030, 1, 7, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		location *model.Location
+		want     time.Time
+	}{
+		{"no location", nil, time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"utc", &model.Location{Timezone: "UTC"}, time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"ahead of utc", &model.Location{Timezone: "Pacific/Auckland"}, time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"behind utc", &model.Location{Timezone: "Pacific/Honolulu"}, time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"unknown timezone", &model.Location{Timezone: "Mars/Olympus"}, time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := locationToday(tt.location, now); !got.Equal(tt.want) {
+				t.Fatalf("locationToday() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDayStart(t *testing.T) {
+	date := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		location *model.Location
+		want     time.Time
+	}{
+		{"no location", nil, date},
+		{"ahead of utc", &model.Location{Timezone: "Pacific/Auckland"}, time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC)},
+		{"behind utc", &model.Location{Timezone: "Pacific/Honolulu"}, time.Date(2030, 1, 8, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dayStart(tt.location, date); !got.Equal(tt.want) {
+				t.Fatalf("dayStart() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
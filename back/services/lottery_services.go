@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// maxLotteryPreferences bounds how many dates a food truck can wish for in a round
+	maxLotteryPreferences = 7
+	// lotteryLossBonus is the weight added per round lost in a row
+	lotteryLossBonus = 0.5
+)
+
+// ErrLotteryWindow is returned when a date is booked directly while a lottery round decides it
+var ErrLotteryWindow = errors.New("bookings for this date are decided by lottery")
+
+// LotteryService runs the lottery rounds deciding the bookings of oversubscribed weeks
+type LotteryService struct {
+	RoundCollection *mongo.Collection
+	EntryCollection *mongo.Collection
+	Reservations    *ReservationService
+}
+
+func NewLotteryService(reservationService *ReservationService) *LotteryService {
+	return &LotteryService{
+		RoundCollection: db.GetCollection("lotteryRound"),
+		EntryCollection: db.GetCollection("lotteryEntry"),
+		Reservations:    reservationService,
+	}
+}
+
+// CreateRound opens a lottery round for a week of a location. Entries are accepted until the cutoff, which must
+// come before the week starts.
+func (s *LotteryService) CreateRound(ctx context.Context, round *model.LotteryRound) error {
+	round.WeekStart = utils.TruncateToDay(round.WeekStart)
+	if !round.Cutoff.After(time.Now()) {
+		return errors.New("cutoff must be in the future")
+	}
+
+	location, err := s.Reservations.Locations.GetLocation(ctx, round.LocationID)
+	if err != nil {
+		return err
+	}
+	if !round.Cutoff.Before(dayStart(location, round.WeekStart)) {
+		return errors.New("cutoff must be before the week starts")
+	}
+	for _, day := range round.Days {
+		if !utils.IsValidDayOfWeek(day) {
+			return fmt.Errorf("invalid day of the week %q", day)
+		}
+	}
+	if round.Days == nil {
+		round.Days = []string{}
+	}
+
+	// The draw books at the cutoff, so it must still leave the lead time before the first date of the round
+	policy, err := s.Reservations.Settings.GetBookingPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	firstDay := firstLotteryDay(round)
+	if round.Cutoff.Add(time.Duration(policy.LeadTimeHours) * time.Hour).After(dayStart(location, firstDay)) {
+		return fmt.Errorf("cutoff must be at least %d hours before %s, the first date of the round", policy.LeadTimeHours, firstDay.Format(utils.DateLayout))
+	}
+
+	// A week of a location is decided by a single round
+	count, err := s.RoundCollection.CountDocuments(ctx, bson.M{
+		"location_id": location.ID,
+		"week_start":  bson.M{"$gt": round.WeekStart.AddDate(0, 0, -7), "$lt": round.WeekStart.AddDate(0, 0, 7)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check lottery rounds: %v", err)
+	}
+	if count > 0 {
+		return errors.New("a lottery round already covers this week")
+	}
+
+	round.ID = primitive.NewObjectID()
+	round.LocationID = location.ID
+	round.Status = model.LotteryOpen
+	round.Seed = 0
+	round.DrawOrder = nil
+	round.DrawnAt = nil
+	round.CreatedAt = time.Now()
+
+	if _, err := s.RoundCollection.InsertOne(ctx, round); err != nil {
+		return fmt.Errorf("failed to create lottery round: %v", err)
+	}
+
+	return nil
+}
+
+// GetRounds lists the lottery rounds, optionally of a single location, most recent week first
+func (s *LotteryService) GetRounds(ctx context.Context, locationID primitive.ObjectID) ([]model.LotteryRound, error) {
+	filter := bson.M{}
+	if !locationID.IsZero() {
+		filter["location_id"] = locationID
+	}
+
+	cursor, err := s.RoundCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "week_start", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rounds := []model.LotteryRound{}
+	if err = cursor.All(ctx, &rounds); err != nil {
+		return nil, err
+	}
+
+	return rounds, nil
+}
+
+// GetRound retrieves a lottery round
+func (s *LotteryService) GetRound(ctx context.Context, roundID primitive.ObjectID) (*model.LotteryRound, error) {
+	var round model.LotteryRound
+	if err := s.RoundCollection.FindOne(ctx, bson.M{"_id": roundID}).Decode(&round); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("lottery round not found")
+		}
+		return nil, err
+	}
+
+	return &round, nil
+}
+
+// GetEntries retrieves the entries of a round, scoped by user ID when given
+func (s *LotteryService) GetEntries(ctx context.Context, roundID primitive.ObjectID, userID primitive.ObjectID) ([]model.LotteryEntry, error) {
+	filter := bson.M{"round_id": roundID}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	cursor, err := s.EntryCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []model.LotteryEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// SubmitEntry records the wishes of a food truck for an open round, replacing its previous entry
+func (s *LotteryService) SubmitEntry(ctx context.Context, roundID primitive.ObjectID, entry *model.LotteryEntry) error {
+	round, err := s.GetRound(ctx, roundID)
+	if err != nil {
+		return err
+	}
+	if round.Status != model.LotteryOpen || !time.Now().Before(round.Cutoff) {
+		return errors.New("lottery round is closed")
+	}
+
+	// Only trucks the user may book for can enter
+	if _, err := s.Reservations.bookableFoodtruck(ctx, entry.FoodTruckID, entry.UserID); err != nil {
+		return err
+	}
+
+	if len(entry.Preferences) == 0 {
+		return errors.New("at least one preference is required")
+	}
+	if len(entry.Preferences) > maxLotteryPreferences {
+		return fmt.Errorf("a lottery entry cannot exceed %d preferences", maxLotteryPreferences)
+	}
+	for i := range entry.Preferences {
+		preference := &entry.Preferences[i]
+		preference.Date = utils.TruncateToDay(preference.Date)
+		if !lotteryCovers(round, preference.Date) {
+			return fmt.Errorf("%s is not decided by this lottery round", preference.Date.Format(utils.DateLayout))
+		}
+	}
+
+	entry.RoundID = round.ID
+	entry.Weight = 0
+	entry.Losses = 0
+	entry.Outcome = model.LotteryPending
+	entry.ReservationIDs = nil
+	entry.Notes = nil
+	entry.CreatedAt = time.Now()
+
+	filter := bson.M{"round_id": round.ID, "food_truck_id": entry.FoodTruckID}
+	var existing model.LotteryEntry
+	err = s.EntryCollection.FindOne(ctx, filter).Decode(&existing)
+	switch {
+	case err == nil:
+		entry.ID = existing.ID
+	case errors.Is(err, mongo.ErrNoDocuments):
+		entry.ID = primitive.NewObjectID()
+	default:
+		return err
+	}
+
+	if _, err := s.EntryCollection.ReplaceOne(ctx, bson.M{"_id": entry.ID}, entry, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to submit lottery entry: %v", err)
+	}
+
+	return nil
+}
+
+// Draw runs the lottery of a round whose cutoff has passed. Entries are weighted, ordered by a seeded draw and then
+// served in turns, each turn booking the next preference of every entry that can still be booked. Winners and
+// losers are notified; the seed, weights and order are stored so that the draw can be replayed.
+func (s *LotteryService) Draw(ctx context.Context, roundID primitive.ObjectID) (*model.LotteryRound, error) {
+	// Claim the round so that concurrent draws do not run it twice
+	var round model.LotteryRound
+	err := s.RoundCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": roundID, "status": model.LotteryOpen, "cutoff": bson.M{"$lte": time.Now()}},
+		bson.M{"$set": bson.M{"status": model.LotteryDrawing}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&round)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("lottery round not found, already drawn or still open")
+		}
+		return nil, err
+	}
+
+	entries, err := s.prepareDraw(ctx, &round)
+	if err != nil {
+		// Nothing was booked yet, give the round back to the next draw
+		if _, resetErr := s.RoundCollection.UpdateOne(ctx, bson.M{"_id": round.ID, "status": model.LotteryDrawing}, bson.M{"$set": bson.M{"status": model.LotteryOpen}}); resetErr != nil {
+			log.Println("Error reopening lottery round:", resetErr)
+		}
+		return nil, err
+	}
+
+	s.allocate(ctx, &round, entries)
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.Outcome = model.LotteryLost
+		if len(entry.ReservationIDs) > 0 {
+			entry.Outcome = model.LotteryWon
+		}
+		if _, err := s.EntryCollection.ReplaceOne(ctx, bson.M{"_id": entry.ID}, entry); err != nil {
+			log.Println("Error storing lottery entry:", err)
+		}
+		s.notifyOutcome(ctx, &round, entry)
+	}
+
+	now := time.Now()
+	round.Status = model.LotteryDrawn
+	round.DrawnAt = &now
+	if _, err := s.RoundCollection.UpdateOne(ctx, bson.M{"_id": round.ID}, bson.M{"$set": bson.M{
+		"status":   round.Status,
+		"drawn_at": now,
+	}}); err != nil {
+		return nil, fmt.Errorf("failed to close lottery round: %v", err)
+	}
+
+	return &round, nil
+}
+
+// prepareDraw weighs the entries of a round being drawn, then stores the seed and the draw order
+func (s *LotteryService) prepareDraw(ctx context.Context, round *model.LotteryRound) ([]model.LotteryEntry, error) {
+	entries, err := s.GetEntries(ctx, round.ID, primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if err := s.weighEntry(ctx, &entries[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	round.Seed = time.Now().UnixNano()
+	round.DrawOrder = drawOrder(entries, round.Seed)
+	if _, err := s.RoundCollection.UpdateOne(ctx, bson.M{"_id": round.ID}, bson.M{"$set": bson.M{
+		"seed":       round.Seed,
+		"draw_order": round.DrawOrder,
+	}}); err != nil {
+		return nil, fmt.Errorf("failed to store lottery draw: %v", err)
+	}
+
+	return entries, nil
+}
+
+// VerifyDraw replays the draw of a round from its stored seed and weights and reports whether it gives the stored order
+func (s *LotteryService) VerifyDraw(ctx context.Context, roundID primitive.ObjectID) (bool, error) {
+	round, err := s.GetRound(ctx, roundID)
+	if err != nil {
+		return false, err
+	}
+	if round.Status != model.LotteryDrawn {
+		return false, errors.New("lottery round has not been drawn")
+	}
+
+	entries, err := s.GetEntries(ctx, round.ID, primitive.NilObjectID)
+	if err != nil {
+		return false, err
+	}
+
+	replayed := drawOrder(entries, round.Seed)
+	if len(replayed) != len(round.DrawOrder) {
+		return false, nil
+	}
+	for i := range replayed {
+		if replayed[i] != round.DrawOrder[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// RunLotterySweeper draws the rounds whose cutoff has passed at every interval until ctx is done
+func (s *LotteryService) RunLotterySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rounds, err := s.dueRounds(ctx)
+		if err != nil {
+			log.Println("Error fetching lottery rounds:", err)
+		}
+		for _, round := range rounds {
+			if _, err := s.Draw(ctx, round.ID); err != nil {
+				log.Println("Error drawing lottery round:", err)
+			} else {
+				log.Printf("Drew lottery round %s", round.ID.Hex())
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueRounds lists the open rounds whose cutoff has passed
+func (s *LotteryService) dueRounds(ctx context.Context) ([]model.LotteryRound, error) {
+	cursor, err := s.RoundCollection.Find(ctx, bson.M{"status": model.LotteryOpen, "cutoff": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rounds []model.LotteryRound
+	if err = cursor.All(ctx, &rounds); err != nil {
+		return nil, err
+	}
+	return rounds, nil
+}
+
+// weighEntry sets the losses in a row and the draw weight of an entry from the history of its food truck
+func (s *LotteryService) weighEntry(ctx context.Context, entry *model.LotteryEntry) error {
+	cursor, err := s.EntryCollection.Find(ctx,
+		bson.M{"food_truck_id": entry.FoodTruckID, "round_id": bson.M{"$ne": entry.RoundID}, "outcome": bson.M{"$in": []string{model.LotteryWon, model.LotteryLost}}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return err
+	}
+	var history []model.LotteryEntry
+	if err := cursor.All(ctx, &history); err != nil {
+		return err
+	}
+
+	entry.Losses = 0
+	for _, past := range history {
+		if past.Outcome == model.LotteryWon {
+			break
+		}
+		entry.Losses++
+	}
+
+	foodtruck, err := findFoodtruck(ctx, s.Reservations.FoodtruckCollection, entry.FoodTruckID)
+	if err != nil {
+		return err
+	}
+	entry.Weight = lotteryWeight(foodtruck.AttendedCount, foodtruck.NoShowCount, entry.Losses)
+	return nil
+}
+
+// lotteryWeight favors trucks that lost the previous rounds and lowers the chances of trucks that do not show up:
+// (1 + 0.5 per loss in a row) times the share of attended reservations, smoothed so that new trucks weigh 1
+func lotteryWeight(attended, noShows, losses int) float64 {
+	reliability := float64(attended+1) / float64(attended+noShows+1)
+	return (1 + lotteryLossBonus*float64(losses)) * reliability
+}
+
+// drawOrder orders the entries by a weighted draw without replacement seeded by seed. Each entry gets the key
+// u^(1/weight) for a uniform u, entries being visited by ID, and the highest keys come first. The same entries,
+// weights and seed always give the same order.
+func drawOrder(entries []model.LotteryEntry, seed int64) []primitive.ObjectID {
+	sorted := make([]model.LotteryEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID.Hex() < sorted[j].ID.Hex() })
+
+	random := rand.New(rand.NewSource(seed))
+	keys := make(map[primitive.ObjectID]float64, len(sorted))
+	for _, entry := range sorted {
+		weight := entry.Weight
+		if weight <= 0 {
+			weight = math.SmallestNonzeroFloat64
+		}
+		keys[entry.ID] = math.Pow(random.Float64(), 1/weight)
+	}
+
+	order := make([]primitive.ObjectID, len(sorted))
+	for i, entry := range sorted {
+		order[i] = entry.ID
+	}
+	sort.SliceStable(order, func(i, j int) bool { return keys[order[i]] > keys[order[j]] })
+	return order
+}
+
+// allocate serves the entries in draw order, in turns: every turn each entry books its next preference that can
+// still be booked, so that a lucky truck does not take every date before the others got one
+func (s *LotteryService) allocate(ctx context.Context, round *model.LotteryRound, entries []model.LotteryEntry) {
+	byID := make(map[primitive.ObjectID]*model.LotteryEntry, len(entries))
+	for i := range entries {
+		byID[entries[i].ID] = &entries[i]
+	}
+	next := make(map[primitive.ObjectID]int, len(entries))
+
+	for booked := true; booked; {
+		booked = false
+		for _, id := range round.DrawOrder {
+			entry := byID[id]
+			for next[id] < len(entry.Preferences) {
+				preference := entry.Preferences[next[id]]
+				next[id]++
+
+				reservation, err := s.bookPreference(ctx, round, entry, preference)
+				if err != nil {
+					entry.Notes = append(entry.Notes, fmt.Sprintf("%s: %v", preference.Date.Format(utils.DateLayout), err))
+					continue
+				}
+				entry.ReservationIDs = append(entry.ReservationIDs, reservation.ID)
+				booked = true
+				break
+			}
+		}
+	}
+}
+
+// bookPreference creates the reservation of a preference on the parking spot of its weekday
+func (s *LotteryService) bookPreference(ctx context.Context, round *model.LotteryRound, entry *model.LotteryEntry, preference model.LotteryPreference) (*model.Reservation, error) {
+	var parkingSpot model.ParkingSpot
+	err := s.Reservations.ParkingSpotCollection.FindOne(ctx, bson.M{"location_id": round.LocationID, "day_of_week": preference.Date.Weekday().String()}).Decode(&parkingSpot)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("spot is not available on this day")
+		}
+		return nil, err
+	}
+
+	reservation := &model.Reservation{
+		SpotID:      parkingSpot.ID,
+		FoodTruckID: entry.FoodTruckID,
+		UserID:      entry.UserID,
+		SpotNumber:  preference.SpotNumber,
+		SlotID:      preference.SlotID,
+		Date:        preference.Date,
+	}
+	if err := s.Reservations.createReservation(ctx, reservation, round.ID); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// notifyOutcome tells the owner of an entry what the draw gave them
+func (s *LotteryService) notifyOutcome(ctx context.Context, round *model.LotteryRound, entry *model.LotteryEntry) {
+	week := round.WeekStart.Format(utils.DateLayout)
+	notification := model.Notification{UserID: entry.UserID, Type: model.NotificationLotteryLost,
+		Message: fmt.Sprintf("The lottery for the week of %s is drawn: none of your dates could be booked", week)}
+	if entry.Outcome == model.LotteryWon {
+		notification.Type = model.NotificationLotteryWon
+		notification.ReservationID = entry.ReservationIDs[0]
+		notification.Message = fmt.Sprintf("The lottery for the week of %s is drawn: %d of your dates were booked", week, len(entry.ReservationIDs))
+	}
+
+	if err := s.Reservations.Notifications.Notify(ctx, &notification); err != nil {
+		log.Println("Error notifying lottery outcome:", err)
+	}
+}
+
+// firstLotteryDay returns the first date decided by a round
+func firstLotteryDay(round *model.LotteryRound) time.Time {
+	for day := 0; day < 7; day++ {
+		date := round.WeekStart.AddDate(0, 0, day)
+		if lotteryCovers(round, date) {
+			return date
+		}
+	}
+	return round.WeekStart
+}
+
+// lotteryCovers reports whether a date is decided by a round
+func lotteryCovers(round *model.LotteryRound, date time.Time) bool {
+	date = utils.TruncateToDay(date)
+	if date.Before(round.WeekStart) || !date.Before(round.WeekStart.AddDate(0, 0, 7)) {
+		return false
+	}
+	return len(round.Days) == 0 || containsString(round.Days, date.Weekday().String())
+}
+
+// checkLotteryWindow refuses direct bookings of a date decided by a lottery round still accepting entries or being
+// drawn. The bookings of the draw itself pass the round being drawn.
+func (s *ReservationService) checkLotteryWindow(ctx context.Context, locationID primitive.ObjectID, date time.Time, drawnRoundID primitive.ObjectID) error {
+	filter := bson.M{
+		"location_id": locationID,
+		"status":      bson.M{"$in": []string{model.LotteryOpen, model.LotteryDrawing}},
+		"week_start":  bson.M{"$gt": date.AddDate(0, 0, -7), "$lte": date},
+	}
+	if !drawnRoundID.IsZero() {
+		filter["_id"] = bson.M{"$ne": drawnRoundID}
+	}
+	cursor, err := s.LotteryCollection.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to check lottery rounds: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rounds []model.LotteryRound
+	if err := cursor.All(ctx, &rounds); err != nil {
+		return fmt.Errorf("failed to check lottery rounds: %v", err)
+	}
+	for i := range rounds {
+		if lotteryCovers(&rounds[i], date) {
+			return fmt.Errorf("%w until %s", ErrLotteryWindow, rounds[i].Cutoff.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
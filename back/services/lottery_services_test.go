@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLotteryWeight(t *testing.T) {
+	tests := []struct {
+		name                      string
+		attended, noShows, losses int
+		want                      float64
+	}{
+		{"new truck", 0, 0, 0, 1},
+		{"reliable truck", 9, 0, 0, 1},
+		{"unreliable truck", 1, 2, 0, 0.5},
+		{"lost twice in a row", 0, 0, 2, 2},
+		{"lost once and unreliable", 1, 2, 1, 0.75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lotteryWeight(tt.attended, tt.noShows, tt.losses); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("lotteryWeight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrawOrderIsReproducible(t *testing.T) {
+	entries := []model.LotteryEntry{
+		{ID: primitive.NewObjectID(), Weight: 1},
+		{ID: primitive.NewObjectID(), Weight: 2},
+		{ID: primitive.NewObjectID(), Weight: 0.5},
+		{ID: primitive.NewObjectID(), Weight: 1.5},
+	}
+	reversed := []model.LotteryEntry{entries[3], entries[2], entries[1], entries[0]}
+
+	first := drawOrder(entries, 42)
+	second := drawOrder(reversed, 42)
+	if len(first) != len(entries) {
+		t.Fatalf("expected %d entries in the draw, got %d", len(entries), len(first))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected the same seed to give the same order, got %v and %v", first, second)
+		}
+	}
+}
+
+func TestDrawOrderFavorsHeavierEntries(t *testing.T) {
+	light := model.LotteryEntry{ID: primitive.NewObjectID(), Weight: 1}
+	heavy := model.LotteryEntry{ID: primitive.NewObjectID(), Weight: 3}
+
+	heavyFirst := 0
+	const draws = 2000
+	for seed := int64(0); seed < draws; seed++ {
+		if drawOrder([]model.LotteryEntry{light, heavy}, seed)[0] == heavy.ID {
+			heavyFirst++
+		}
+	}
+
+	// The heavy entry comes first with probability 3/4
+	if share := float64(heavyFirst) / draws; share < 0.7 || share > 0.8 {
+		t.Fatalf("expected the heavier entry to come first about 75%% of the time, got %.2f", share)
+	}
+}
+
+func TestLotteryCovers(t *testing.T) {
+	weekStart := time.Date(2030, 6, 3, 0, 0, 0, 0, time.UTC) // Monday
+	round := &model.LotteryRound{WeekStart: weekStart, Days: []string{"Tuesday", "Friday"}}
+
+	tests := []struct {
+		date time.Time
+		want bool
+	}{
+		{weekStart.AddDate(0, 0, 1), true},
+		{weekStart.AddDate(0, 0, 4), true},
+		{weekStart.AddDate(0, 0, 2), false},
+		{weekStart.AddDate(0, 0, 8), false},
+		{weekStart.AddDate(0, 0, -6), false},
+	}
+	for _, tt := range tests {
+		if got := lotteryCovers(round, tt.date); got != tt.want {
+			t.Fatalf("lotteryCovers(%s) = %v, want %v", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+
+	round.Days = nil
+	if !lotteryCovers(round, weekStart.AddDate(0, 0, 2)) {
+		t.Fatal("expected a round without days to cover the whole week")
+	}
+}
+
+func TestFirstLotteryDay(t *testing.T) {
+	weekStart := time.Date(2030, 6, 3, 0, 0, 0, 0, time.UTC) // Monday
+	round := &model.LotteryRound{WeekStart: weekStart, Days: []string{"Friday", "Tuesday"}}
+	if got := firstLotteryDay(round); !got.Equal(weekStart.AddDate(0, 0, 1)) {
+		t.Fatalf("expected the Tuesday to come first, got %s", got.Format(utils.DateLayout))
+	}
+
+	round.Days = nil
+	if got := firstLotteryDay(round); !got.Equal(weekStart) {
+		t.Fatalf("expected a round without days to start with its week, got %s", got.Format(utils.DateLayout))
+	}
+}
+
+func TestCreateRoundRequiresLeadTimeBeforeFirstDay(t *testing.T) {
+	database := newTestDatabase(t)
+	service := newTestReservationService(t, database)
+	lottery := &LotteryService{RoundCollection: service.LotteryCollection, EntryCollection: database.Collection("lotteryEntry"), Reservations: service}
+	ctx := context.Background()
+	weekStart := utils.TruncateToDay(time.Now().AddDate(0, 0, 14))
+
+	// The default policy asks for 24 hours of lead time
+	round := &model.LotteryRound{WeekStart: weekStart, Cutoff: weekStart.Add(-12 * time.Hour)}
+	if err := lottery.CreateRound(ctx, round); err == nil {
+		t.Fatal("expected a cutoff within the lead time of the first day to be refused")
+	}
+
+	round = &model.LotteryRound{WeekStart: weekStart, Days: []string{weekStart.AddDate(0, 0, 1).Weekday().String()}, Cutoff: weekStart.Add(-12 * time.Hour)}
+	if err := lottery.CreateRound(ctx, round); err != nil {
+		t.Fatalf("expected the cutoff to leave the lead time before the first covered day, got %v", err)
+	}
+}
+
+func TestDirectBookingsRefusedWhileDrawing(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2})
+	location, err := service.Locations.GetLocation(ctx, primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to fetch location: %v", err)
+	}
+
+	round := model.LotteryRound{ID: primitive.NewObjectID(), LocationID: location.ID, WeekStart: date, Status: model.LotteryDrawing}
+	if _, err := service.LotteryCollection.InsertOne(ctx, round); err != nil {
+		t.Fatalf("failed to insert lottery round: %v", err)
+	}
+
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	reservation := &model.Reservation{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 1, Date: date}
+	if err := service.CreateReservation(ctx, reservation); !errors.Is(err, ErrLotteryWindow) {
+		t.Fatalf("expected a direct booking to be refused during the draw, got %v", err)
+	}
+	if err := service.createReservation(ctx, reservation, round.ID); err != nil {
+		t.Fatalf("expected the draw to book the date, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// NotificationService stores the messages shown to users about changes to their reservations
+type NotificationService struct {
+	NotificationCollection *mongo.Collection
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{
+		NotificationCollection: db.GetCollection("notification"),
+	}
+}
+
+// Notify stores a notification for a user
+func (s *NotificationService) Notify(ctx context.Context, notification *model.Notification) error {
+	notification.ID = primitive.NewObjectID()
+	notification.Read = false
+	notification.CreatedAt = time.Now()
+
+	if _, err := s.NotificationCollection.InsertOne(ctx, notification); err != nil {
+		return fmt.Errorf("failed to store notification: %v", err)
+	}
+
+	return nil
+}
+
+// GetUserNotifications retrieves the notifications of a user, most recent first
+func (s *NotificationService) GetUserNotifications(ctx context.Context, userID primitive.ObjectID) ([]model.Notification, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.NotificationCollection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []model.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+func (s *NotificationService) MarkNotificationRead(ctx context.Context, notificationID primitive.ObjectID, userID primitive.ObjectID) error {
+	result, err := s.NotificationCollection.UpdateOne(ctx,
+		bson.M{"_id": notificationID, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return errors.New("failed to update notification")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("notification not found")
+	}
+
+	return nil
+}
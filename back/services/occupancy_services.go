@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var (
+	// ErrSpotConflict is returned when the requested spot number is already taken on that date
+	ErrSpotConflict = errors.New("spot number already reserved for this day")
+	// ErrSpotFull is returned when every spot of the parking spot is taken on that date
+	ErrSpotFull = errors.New("no available spots for this day")
+)
+
+// OccupancyService keeps track of the spot numbers taken per parking spot, calendar date and time slot
+type OccupancyService struct {
+	OccupancyCollection   *mongo.Collection
+	ReservationCollection *mongo.Collection
+	ParkingSpotCollection *mongo.Collection
+}
+
+func NewOccupancyService() *OccupancyService {
+	return &OccupancyService{
+		OccupancyCollection:   db.GetCollection("spotOccupancy"),
+		ReservationCollection: db.GetCollection("reservation"),
+		ParkingSpotCollection: db.GetCollection("parkingSpot"),
+	}
+}
+
+// GetOccupancy returns the occupancy of a parking spot on a date and slot (empty when nothing is booked)
+func (s *OccupancyService) GetOccupancy(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string) (*model.SpotOccupancy, error) {
+	date = utils.TruncateToDay(date)
+	occupancy := model.SpotOccupancy{SpotID: spotID, Date: date, SlotID: slotID, TakenSpots: []int{}}
+
+	err := s.OccupancyCollection.FindOne(ctx, bson.M{"spot_id": spotID, "date": date, "slot_id": slotID}).Decode(&occupancy)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to fetch spot occupancy: %v", err)
+	}
+
+	return &occupancy, nil
+}
+
+// EnsureIndexes creates the unique index guaranteeing a single occupancy document per spot, date and slot.
+// Occupancies created before slots existed cover the whole day and get an empty slot.
+func (s *OccupancyService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.OccupancyCollection.UpdateMany(ctx,
+		bson.M{"slot_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"slot_id": ""}})
+	if err != nil {
+		return fmt.Errorf("failed to migrate spot occupancy slots: %v", err)
+	}
+
+	// The former per-day index would reject a second slot on the same date
+	if _, err := s.OccupancyCollection.Indexes().DropOne(ctx, "spot_id_1_date_1"); err != nil {
+		var commandErr mongo.CommandError
+		if !errors.As(err, &commandErr) || (commandErr.Name != "IndexNotFound" && commandErr.Name != "NamespaceNotFound") {
+			return fmt.Errorf("failed to drop spot occupancy index: %v", err)
+		}
+	}
+
+	_, err = s.OccupancyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "spot_id", Value: 1}, {Key: "date", Value: 1}, {Key: "slot_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create spot occupancy index: %v", err)
+	}
+	return nil
+}
+
+// reserveAttempts bounds how many times Reserve retries after losing the race to create an occupancy document
+const reserveAttempts = 3
+
+// Reserve atomically marks a spot number as taken on a date and slot, as long as it is free and capacity is left.
+// The update only matches when the number is free and the count is below capacity; otherwise the upsert
+// collides with the unique (spot_id, date, slot_id) index and the booking is rejected as a conflict. Two first
+// bookings of a date also collide on that index, so the loser retries while its number is free and capacity left.
+func (s *OccupancyService) Reserve(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string, spotNumber int, maxCapacity int) error {
+	date = utils.TruncateToDay(date)
+	filter := bson.M{
+		"spot_id":        spotID,
+		"date":           date,
+		"slot_id":        slotID,
+		"taken_spots":    bson.M{"$ne": spotNumber},
+		"reserved_count": bson.M{"$lt": maxCapacity},
+	}
+	update := bson.M{
+		"$inc":  bson.M{"reserved_count": 1},
+		"$push": bson.M{"taken_spots": spotNumber},
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, err := s.OccupancyCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to reserve spot number %d: %v", spotNumber, err)
+		}
+
+		// The occupancy document exists but refused the booking: tell the caller why
+		occupancy, err := s.GetOccupancy(ctx, spotID, date, slotID)
+		if err != nil {
+			return err
+		}
+		if containsInt(occupancy.TakenSpots, spotNumber) {
+			return ErrSpotConflict
+		}
+		if occupancy.ReservedCount >= maxCapacity {
+			return ErrSpotFull
+		}
+
+		// Another booking created the document meanwhile, which leaves room for this one
+		if attempt == reserveAttempts {
+			return ErrSpotConflict
+		}
+	}
+}
+
+// Move atomically swaps a taken spot number for another free one on the same date and slot
+func (s *OccupancyService) Move(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string, fromNumber, toNumber int) error {
+	filter := bson.M{
+		"spot_id":     spotID,
+		"date":        utils.TruncateToDay(date),
+		"slot_id":     slotID,
+		"taken_spots": bson.M{"$all": []int{fromNumber}, "$nin": []int{toNumber}},
+	}
+	update := bson.M{"$set": bson.M{"taken_spots.$[old]": toNumber}}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"old": fromNumber}}})
+
+	result, err := s.OccupancyCollection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("failed to move spot number %d: %v", fromNumber, err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrSpotConflict
+	}
+	return nil
+}
+
+// Release frees a spot number previously taken on a date and slot
+func (s *OccupancyService) Release(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string, spotNumber int) error {
+	filter := bson.M{"spot_id": spotID, "date": utils.TruncateToDay(date), "slot_id": slotID, "taken_spots": spotNumber}
+	update := bson.M{
+		"$inc":  bson.M{"reserved_count": -1},
+		"$pull": bson.M{"taken_spots": spotNumber},
+	}
+
+	_, err := s.OccupancyCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to release spot number %d: %v", spotNumber, err)
+	}
+	return nil
+}
+
+// BackfillOccupancy rebuilds the occupancy collection from existing reservations when it is still empty,
+// and drops the legacy per-weekday counters from the parking spots.
+func (s *OccupancyService) BackfillOccupancy(ctx context.Context) error {
+	count, err := s.OccupancyCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	cursor, err := s.ReservationCollection.Find(ctx, bson.M{"status": activeStatusFilter()})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []model.Reservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return err
+	}
+
+	// Legacy data may hold double bookings, so counters are rebuilt without the capacity guard
+	for _, reservation := range reservations {
+		filter := bson.M{"spot_id": reservation.SpotID, "date": utils.TruncateToDay(reservation.Date), "slot_id": reservation.SlotID}
+		update := bson.M{
+			"$inc":      bson.M{"reserved_count": 1},
+			"$addToSet": bson.M{"taken_spots": reservation.SpotNumber},
+		}
+		if _, err := s.OccupancyCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.ParkingSpotCollection.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"reserved_count": "", "reserved_spots": ""}})
+	return err
+}
@@ -10,52 +10,85 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/net/context"
+	"sort"
+	"time"
 )
 
+// maxAvailabilityRange bounds the date range of a single availability query
+const maxAvailabilityRange = 92 * 24 * time.Hour
+
 type ParkingSpotService struct {
 	ParkingSpotCollection *mongo.Collection
+	ReservationCollection *mongo.Collection
+	HoldCollection        *mongo.Collection
+	Occupancy             *OccupancyService
+	FoodtruckCollection   *mongo.Collection
+	Closures              *ClosureService
+	Locations             *LocationService
+	Notifications         *NotificationService
 }
 
 func NewParkingSpotService() *ParkingSpotService {
 	return &ParkingSpotService{
 		ParkingSpotCollection: db.GetCollection("parkingSpot"),
+		ReservationCollection: db.GetCollection("reservation"),
+		HoldCollection:        db.GetCollection("reservationHold"),
+		Occupancy:             NewOccupancyService(),
+		FoodtruckCollection:   db.GetCollection("foodtruck"),
+		Closures:              NewClosureService(),
+		Locations:             NewLocationService(),
+		Notifications:         NewNotificationService(),
 	}
 }
 
-// CreateParkingSpot Create parking spot for a specific day of the week
-func (s *ParkingSpotService) CreateParkingSpot(dayOfWeek string, ctx context.Context) (*model.ParkingSpot, error) {
+// CreateParkingSpot Create parking spot of a location (the default one when zero) for a specific day of the week.
+// Without spot numbers the default layout is used: 6 spots on Friday, 7 on other days. Without slots the day is
+// booked as a whole.
+func (s *ParkingSpotService) CreateParkingSpot(locationID primitive.ObjectID, dayOfWeek string, spotNumbers []int, maxCapacity int, slots []model.TimeSlot, ctx context.Context) (*model.ParkingSpot, error) {
 	// Validate the day of the week
 	if !utils.IsValidDayOfWeek(dayOfWeek) {
 		return nil, errors.New("invalid day of the week")
 	}
 
+	// Ensure the location opens on that day
+	location, err := s.Locations.GetLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(location.OpeningDays, dayOfWeek) {
+		return nil, fmt.Errorf("%s is not open on %s", location.Name, dayOfWeek)
+	}
+
 	// Check if the parking spot already exists
 	var existingSpot model.ParkingSpot
-	err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"day_of_week": dayOfWeek}).Decode(&existingSpot)
+	err = s.ParkingSpotCollection.FindOne(ctx, bson.M{"location_id": location.ID, "day_of_week": dayOfWeek}).Decode(&existingSpot)
 	if err == nil {
 		return nil, errors.New("parking spot already exists for this day")
 	} else if err != mongo.ErrNoDocuments {
 		return nil, fmt.Errorf("failed to query parking spot: %v", err)
 	}
 
-	// Determine the total number of spots based on the day
-	var totalSpaces int
-	var spotNumbers []int
-	if dayOfWeek == "Friday" {
-		totalSpaces = 6
-		spotNumbers = []int{1, 2, 3, 4, 5, 6} // Friday has 6 spots
-	} else {
-		totalSpaces = 7
-		spotNumbers = []int{1, 2, 3, 4, 5, 6, 7} // Other days have 7 spots
+	// Determine the spot numbers and capacity of the day
+	if len(spotNumbers) == 0 {
+		spotNumbers = defaultSpotNumbers(dayOfWeek)
+	}
+	totalSpaces, err := validateLayout(spotNumbers, maxCapacity)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSlots(slots, len(spotNumbers)); err != nil {
+		return nil, err
 	}
+	sort.Ints(spotNumbers)
 
 	// Create the new parking spot document
 	newSpot := model.ParkingSpot{
 		ID:            primitive.NewObjectID(),
+		LocationID:    location.ID,
 		Day:           dayOfWeek,
 		MaxCapacity:   totalSpaces,
-		ReservedCount: 0,
 		SpotNumbers:   spotNumbers,
+		Slots:         slots,
 		ReservedSpots: []int{},
 	}
 
@@ -68,9 +101,19 @@ func (s *ParkingSpotService) CreateParkingSpot(dayOfWeek string, ctx context.Con
 	return &newSpot, nil
 }
 
-// ListAllParkingSpots retrieves all parking spots, filtered by day if specified
-func (s *ParkingSpotService) ListAllParkingSpots(dayOfWeek string, ctx context.Context) ([]model.ParkingSpot, error) {
+// ListAllParkingSpots retrieves all parking spots, filtered by location and day if specified.
+// When a date is given, only the spots of that weekday are returned along with what is taken and free on that date,
+// for the given slot or for each slot of the day; with a food truck, only the spot numbers able to host it are
+// listed as free.
+func (s *ParkingSpotService) ListAllParkingSpots(locationID primitive.ObjectID, dayOfWeek string, date time.Time, slotID string, foodTruckID primitive.ObjectID, ctx context.Context) ([]model.ParkingSpot, error) {
 	filter := bson.M{}
+	if !locationID.IsZero() {
+		filter["location_id"] = locationID
+	}
+	if !date.IsZero() {
+		date = utils.TruncateToDay(date)
+		dayOfWeek = date.Weekday().String()
+	}
 	if dayOfWeek != "" {
 		filter["day_of_week"] = dayOfWeek
 	}
@@ -88,12 +131,227 @@ func (s *ParkingSpotService) ListAllParkingSpots(dayOfWeek string, ctx context.C
 		if err := cursor.Decode(&spot); err != nil {
 			return nil, fmt.Errorf("failed to decode parking spot: %v", err)
 		}
+		spot.ReservedSpots = []int{}
+
+		if !date.IsZero() {
+			if err := s.fillOccupancy(ctx, &spot, date, slotID, foodTruckID); err != nil {
+				return nil, err
+			}
+		}
 		spots = append(spots, spot)
 	}
 
 	return spots, nil
 }
 
+// fillOccupancy sets the reserved, closed and free spot numbers of a parking spot for a date, free ones being
+// restricted to those able to host the food truck when one is given. A parking spot with slots gets the
+// occupancy of the requested slot, or one entry per slot when none is requested.
+func (s *ParkingSpotService) fillOccupancy(ctx context.Context, spot *model.ParkingSpot, date time.Time, slotID string, foodTruckID primitive.ObjectID) error {
+	slots := spotSlots(spot)
+	if slotID != "" {
+		slot, err := resolveSlot(spot, slotID)
+		if err != nil {
+			return err
+		}
+		slots = []*model.TimeSlot{slot}
+	}
+
+	location, err := s.Locations.GetLocation(ctx, spot.LocationID)
+	if err != nil {
+		return err
+	}
+
+	var requirements *model.TruckRequirements
+	if !foodTruckID.IsZero() {
+		truck, err := truckRequirements(ctx, s.FoodtruckCollection, foodTruckID)
+		if err != nil {
+			return err
+		}
+		requirements = &truck
+	}
+
+	closures, err := s.Closures.ListClosures(ctx, spot.LocationID, date, date)
+	if err != nil {
+		return err
+	}
+	closed := closedSpotNumbers(closures, date, spot.SpotNumbers)
+
+	spot.Date = &date
+	spot.ClosedSpots = closed
+	for _, slot := range slots {
+		occupancy, err := s.Occupancy.GetOccupancy(ctx, spot.ID, date, slotKey(slot))
+		if err != nil {
+			return err
+		}
+		availability := slotAvailability(spot, location, date, slot, *occupancy, closed, requirements)
+
+		spot.ReservedCount += occupancy.ReservedCount
+		if len(slots) > 1 || slot != nil && slotID == "" {
+			spot.SlotAvailability = append(spot.SlotAvailability, availability)
+			continue
+		}
+		spot.SlotID = slotKey(slot)
+		spot.ReservedSpots = availability.TakenSpots
+		spot.FreeSpots = availability.FreeSpots
+	}
+
+	return nil
+}
+
+// slotAvailability computes the free, taken and closed spot numbers of a parking spot for a date and slot.
+// Closed spot numbers are neither free nor counted in the remaining capacity; with truck requirements, only the
+// spot numbers able to host the truck are listed as free.
+func slotAvailability(spot *model.ParkingSpot, location *model.Location, date time.Time, slot *model.TimeSlot, occupancy model.SpotOccupancy, closed []int, requirements *model.TruckRequirements) model.DayAvailability {
+	taken := occupancy.TakenSpots
+	if taken == nil {
+		taken = []int{}
+	}
+
+	capacity := slotCapacity(spot, slot)
+	remaining := capacity - occupancy.ReservedCount
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	free := freeSpotNumbers(spot.SpotNumbers, append(append([]int{}, closed...), taken...), remaining)
+	if requirements != nil {
+		free = compatibleSpotNumbers(location, free, *requirements)
+	}
+	if remaining > len(free) {
+		remaining = len(free)
+	}
+
+	return model.DayAvailability{
+		Date:              date,
+		Slot:              slot,
+		LocationID:        spot.LocationID,
+		Day:               spot.Day,
+		SpotID:            spot.ID,
+		MaxCapacity:       capacity,
+		RemainingCapacity: remaining,
+		FreeSpots:         free,
+		TakenSpots:        taken,
+		ClosedSpots:       closed,
+		Closed:            len(spot.SpotNumbers) > 0 && len(closed) == len(spot.SpotNumbers),
+	}
+}
+
+// GetAvailability returns, for every date between from and to (inclusive) on which a parking spot is open and
+// every slot of that day, the free and taken spot numbers and the remaining capacity. A zero location covers every location.
+// With a food truck, only the spot numbers able to host it are listed as free.
+func (s *ParkingSpotService) GetAvailability(ctx context.Context, locationID primitive.ObjectID, from, to time.Time, foodTruckID primitive.ObjectID) ([]model.DayAvailability, error) {
+	from = utils.TruncateToDay(from)
+	to = utils.TruncateToDay(to)
+	if to.Before(from) {
+		return nil, errors.New("'to' must not be before 'from'")
+	}
+	if to.Sub(from) > maxAvailabilityRange {
+		return nil, fmt.Errorf("date range cannot exceed %d days", int(maxAvailabilityRange.Hours()/24))
+	}
+
+	spots, err := s.ListAllParkingSpots(locationID, "", time.Time{}, "", primitive.NilObjectID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.Locations.GetLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	locationsByID := make(map[primitive.ObjectID]model.Location, len(locations))
+	for _, location := range locations {
+		locationsByID[location.ID] = location
+	}
+
+	var requirements *model.TruckRequirements
+	if !foodTruckID.IsZero() {
+		truck, err := truckRequirements(ctx, s.FoodtruckCollection, foodTruckID)
+		if err != nil {
+			return nil, err
+		}
+		requirements = &truck
+	}
+
+	spotsByDay := make(map[string][]model.ParkingSpot, len(spots))
+	spotIDs := make([]primitive.ObjectID, 0, len(spots))
+	for _, spot := range spots {
+		spotsByDay[spot.Day] = append(spotsByDay[spot.Day], spot)
+		spotIDs = append(spotIDs, spot.ID)
+	}
+
+	// Fetch every occupancy of the range in a single query
+	cursor, err := s.Occupancy.OccupancyCollection.Find(ctx, bson.M{
+		"spot_id": bson.M{"$in": spotIDs},
+		"date":    bson.M{"$gte": from, "$lte": to},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch spot occupancy: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var occupancies []model.SpotOccupancy
+	if err := cursor.All(ctx, &occupancies); err != nil {
+		return nil, fmt.Errorf("failed to decode spot occupancy: %v", err)
+	}
+
+	closures, err := s.Closures.ListClosures(ctx, locationID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type occupancyKey struct {
+		spotID primitive.ObjectID
+		date   time.Time
+		slotID string
+	}
+	occupancyByKey := make(map[occupancyKey]model.SpotOccupancy, len(occupancies))
+	for _, occupancy := range occupancies {
+		occupancyByKey[occupancyKey{occupancy.SpotID, occupancy.Date.UTC(), occupancy.SlotID}] = occupancy
+	}
+
+	availability := []model.DayAvailability{}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		for i := range spotsByDay[date.Weekday().String()] {
+			spot := &spotsByDay[date.Weekday().String()][i]
+
+			// Skip the days on which the location is not open
+			location, ok := locationsByID[spot.LocationID]
+			if ok && !isOpeningDay(&location, date) {
+				continue
+			}
+
+			closed := closedSpotNumbers(closuresOfLocation(closures, spot.LocationID), date, spot.SpotNumbers)
+			for _, slot := range spotSlots(spot) {
+				occupancy := occupancyByKey[occupancyKey{spot.ID, date, slotKey(slot)}]
+				availability = append(availability, slotAvailability(spot, &location, date, slot, occupancy, closed, requirements))
+			}
+		}
+	}
+
+	return availability, nil
+}
+
+// freeSpotNumbers lists the spot numbers not taken, or none when no capacity remains
+func freeSpotNumbers(spotNumbers, taken []int, remaining int) []int {
+	free := []int{}
+	if remaining <= 0 {
+		return free
+	}
+
+	takenSet := make(map[int]bool, len(taken))
+	for _, num := range taken {
+		takenSet[num] = true
+	}
+	for _, num := range spotNumbers {
+		if !takenSet[num] {
+			free = append(free, num)
+		}
+	}
+
+	return free
+}
+
 // UpdateReservationStatus updates the reservation status of a parking spot
 func (s *ParkingSpotService) UpdateReservationStatus(spotID primitive.ObjectID, reserved bool, ctx context.Context) error {
 	update := bson.M{"$set": bson.M{"reserved": reserved}}
@@ -103,3 +361,16 @@ func (s *ParkingSpotService) UpdateReservationStatus(spotID primitive.ObjectID,
 	}
 	return nil
 }
+
+// GetParkingSpot retrieves a parking spot by ID
+func (s *ParkingSpotService) GetParkingSpot(ctx context.Context, spotID primitive.ObjectID) (*model.ParkingSpot, error) {
+	var spot model.ParkingSpot
+	if err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": spotID}).Decode(&spot); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("parking spot not found")
+		}
+		return nil, err
+	}
+
+	return &spot, nil
+}
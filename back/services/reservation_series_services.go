@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// maxSeriesOccurrences bounds how many reservations a single series may create
+const maxSeriesOccurrences = 52
+
+// ErrSeriesNotBooked is returned when none of the occurrences of a new series could be booked
+var ErrSeriesNotBooked = errors.New("no occurrence of the series could be booked")
+
+// CreateReservationSeries books the series' spot number on every matching weekday, each occurrence going through
+// the same validation as CreateReservation, and reports the outcome per date. When no occurrence is booked the
+// series is not kept and ErrSeriesNotBooked is returned along with the outcomes.
+func (s *ReservationService) CreateReservationSeries(ctx context.Context, series *model.ReservationSeries) ([]model.SeriesOccurrence, error) {
+	weekday, ok := utils.ParseWeekday(series.Weekday)
+	if !ok {
+		return nil, errors.New("invalid day of the week")
+	}
+	if series.Count <= 0 && series.EndDate == nil {
+		return nil, errors.New("either count or end_date is required")
+	}
+	if series.Count > maxSeriesOccurrences {
+		return nil, fmt.Errorf("a series cannot exceed %d occurrences", maxSeriesOccurrences)
+	}
+
+	dates := seriesDates(series.StartDate, series.EndDate, series.Count, weekday)
+	if len(dates) == 0 {
+		return nil, errors.New("no occurrence between start_date and end_date")
+	}
+
+	location, err := s.Locations.GetLocation(ctx, series.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the parking spot of the location open on that weekday
+	var parkingSpot model.ParkingSpot
+	err = s.ParkingSpotCollection.FindOne(ctx, bson.M{"location_id": location.ID, "day_of_week": series.Weekday}).Decode(&parkingSpot)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("spot is not available on this day")
+		}
+		return nil, err
+	}
+
+	// Every occurrence books the same slot of the day
+	if _, err := resolveSlot(&parkingSpot, series.SlotID); err != nil {
+		return nil, err
+	}
+
+	series.ID = primitive.NewObjectID()
+	series.LocationID = location.ID
+	series.StartDate = utils.TruncateToDay(series.StartDate)
+	series.SkippedDates = []time.Time{}
+	series.Status = model.SeriesActive
+	series.CreatedAt = time.Now()
+	if _, err := s.SeriesCollection.InsertOne(ctx, series); err != nil {
+		return nil, fmt.Errorf("failed to create reservation series: %v", err)
+	}
+
+	booked := 0
+	occurrences := make([]model.SeriesOccurrence, 0, len(dates))
+	for _, date := range dates {
+		reservation := model.Reservation{
+			SpotID:      parkingSpot.ID,
+			FoodTruckID: series.FoodTruckID,
+			UserID:      series.UserID,
+			SpotNumber:  series.SpotNumber,
+			SlotID:      series.SlotID,
+			Date:        date,
+			SeriesID:    series.ID,
+		}
+
+		occurrence := model.SeriesOccurrence{Date: date}
+		if err := s.CreateReservation(ctx, &reservation); err != nil {
+			// Closed dates are left out of the series rather than reported as failures
+			occurrence.Status = model.OccurrenceFailed
+			if errors.Is(err, ErrSpotClosed) {
+				occurrence.Status = model.OccurrenceSkipped
+			}
+			occurrence.Error = err.Error()
+		} else {
+			occurrence.Status = model.OccurrenceBooked
+			occurrence.ReservationID = reservation.ID
+			booked++
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	// A series without any reservation is not worth keeping
+	if booked == 0 {
+		if _, err := s.SeriesCollection.DeleteOne(ctx, bson.M{"_id": series.ID}); err != nil {
+			return nil, fmt.Errorf("failed to remove reservation series: %v", err)
+		}
+		return occurrences, ErrSeriesNotBooked
+	}
+
+	return occurrences, nil
+}
+
+// GetReservationSeries retrieves a series and its reservations, scoped by user ID when given
+func (s *ReservationService) GetReservationSeries(ctx context.Context, seriesID primitive.ObjectID, userID primitive.ObjectID) (*model.ReservationSeries, []model.Reservation, error) {
+	series, err := s.findSeries(ctx, seriesID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursor, err := s.ReservationCollection.Find(ctx, bson.M{"series_id": series.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []model.Reservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, nil, err
+	}
+
+	return series, reservations, nil
+}
+
+// CancelReservationSeries cancels the series and every occurrence that has not happened yet
+func (s *ReservationService) CancelReservationSeries(ctx context.Context, seriesID primitive.ObjectID, userID primitive.ObjectID) (int, error) {
+	series, err := s.findSeries(ctx, seriesID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := s.ReservationCollection.Find(ctx, bson.M{
+		"series_id": series.ID,
+		"date":      bson.M{"$gt": time.Now()},
+		"status":    activeStatusFilter(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var upcoming []model.Reservation
+	if err = cursor.All(ctx, &upcoming); err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, reservation := range upcoming {
+		if _, err := s.DeleteReservation(ctx, reservation.ID, userID); err != nil {
+			return cancelled, err
+		}
+		cancelled++
+	}
+
+	_, err = s.SeriesCollection.UpdateOne(ctx, bson.M{"_id": series.ID}, bson.M{"$set": bson.M{"status": model.SeriesCancelled}})
+	if err != nil {
+		return cancelled, errors.New("failed to cancel reservation series")
+	}
+
+	return cancelled, nil
+}
+
+// SkipReservationSeriesDate cancels a single occurrence of a series
+func (s *ReservationService) SkipReservationSeriesDate(ctx context.Context, seriesID primitive.ObjectID, userID primitive.ObjectID, date time.Time) error {
+	series, err := s.findSeries(ctx, seriesID, userID)
+	if err != nil {
+		return err
+	}
+	date = utils.TruncateToDay(date)
+
+	var reservation model.Reservation
+	err = s.ReservationCollection.FindOne(ctx, bson.M{"series_id": series.ID, "date": date, "status": activeStatusFilter()}).Decode(&reservation)
+	if err != nil {
+		return errors.New("no reservation of this series on that date")
+	}
+
+	if _, err := s.DeleteReservation(ctx, reservation.ID, userID); err != nil {
+		return err
+	}
+
+	_, err = s.SeriesCollection.UpdateOne(ctx, bson.M{"_id": series.ID}, bson.M{"$addToSet": bson.M{"skipped_dates": date}})
+	if err != nil {
+		return errors.New("failed to update reservation series")
+	}
+
+	return nil
+}
+
+// findSeries loads a series, scoped by user ID when given
+func (s *ReservationService) findSeries(ctx context.Context, seriesID primitive.ObjectID, userID primitive.ObjectID) (*model.ReservationSeries, error) {
+	filter := bson.M{"_id": seriesID}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	var series model.ReservationSeries
+	if err := s.SeriesCollection.FindOne(ctx, filter).Decode(&series); err != nil {
+		return nil, errors.New("reservation series not found")
+	}
+
+	return &series, nil
+}
+
+// seriesDates lists the weekday occurrences from start, bounded by end (inclusive) and/or count
+func seriesDates(start time.Time, end *time.Time, count int, weekday time.Weekday) []time.Time {
+	date := utils.TruncateToDay(start)
+	date = date.AddDate(0, 0, (int(weekday)-int(date.Weekday())+7)%7)
+
+	var dates []time.Time
+	for len(dates) < maxSeriesOccurrences {
+		if count > 0 && len(dates) >= count {
+			break
+		}
+		if end != nil && date.After(utils.TruncateToDay(*end)) {
+			break
+		}
+		dates = append(dates, date)
+		date = date.AddDate(0, 0, 7)
+	}
+
+	return dates
+}
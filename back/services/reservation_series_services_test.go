@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestSeriesDates(t *testing.T) {
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC) // A Sunday
+	end := time.Date(2026, 11, 24, 0, 0, 0, 0, time.UTC)  // A Tuesday
+
+	tests := []struct {
+		name     string
+		end      *time.Time
+		count    int
+		expected []time.Time
+	}{
+		{
+			name:  "Bounded by count",
+			count: 3,
+			expected: []time.Time{
+				time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Bounded by an inclusive end date",
+			end:  &end,
+			expected: []time.Time{
+				time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 24, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "Count stops before the end date",
+			end:   &end,
+			count: 1,
+			expected: []time.Time{
+				time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := seriesDates(start, tt.end, tt.count, time.Tuesday)
+			if len(dates) != len(tt.expected) {
+				t.Fatalf("expected %d dates, got %v", len(tt.expected), dates)
+			}
+			for i := range dates {
+				if !dates[i].Equal(tt.expected[i]) {
+					t.Fatalf("occurrence %d: expected %v, got %v", i, tt.expected[i], dates[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateReservationSeriesKeepsOnlyBookedSeries(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	insertTestParkingSpot(t, service, date, []int{1})
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+
+	// Spot number 5 is not in the layout, so no occurrence can be booked
+	failing := &model.ReservationSeries{FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 5, Weekday: date.Weekday().String(), StartDate: date, Count: 2}
+	occurrences, err := service.CreateReservationSeries(ctx, failing)
+	if !errors.Is(err, ErrSeriesNotBooked) {
+		t.Fatalf("expected the series to be refused, got %v", err)
+	}
+	if len(occurrences) != 2 || occurrences[0].Status != model.OccurrenceFailed {
+		t.Fatalf("expected every occurrence to be reported as failed, got %+v", occurrences)
+	}
+	if count, err := service.SeriesCollection.CountDocuments(ctx, bson.M{}); err != nil || count != 0 {
+		t.Fatalf("expected the series not to be kept, got %d, %v", count, err)
+	}
+
+	booked := &model.ReservationSeries{FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 1, Weekday: date.Weekday().String(), StartDate: date, Count: 2}
+	if _, err := service.CreateReservationSeries(ctx, booked); err != nil {
+		t.Fatalf("failed to create series: %v", err)
+	}
+	if count, err := service.SeriesCollection.CountDocuments(ctx, bson.M{"_id": booked.ID}); err != nil || count != 1 {
+		t.Fatalf("expected the booked series to be kept, got %d, %v", count, err)
+	}
+}
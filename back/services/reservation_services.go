@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"gitlab.com/hooly2/back/db"
 	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,6 +17,21 @@ type ReservationService struct {
 	ReservationCollection *mongo.Collection
 	ParkingSpotCollection *mongo.Collection
 	UserCollection        *mongo.Collection
+	FoodtruckCollection   *mongo.Collection
+	SeriesCollection      *mongo.Collection
+	LotteryCollection     *mongo.Collection
+	HoldCollection        *mongo.Collection
+	WaitlistCollection    *mongo.Collection
+	Occupancy             *OccupancyService
+	Closures              *ClosureService
+	Locations             *LocationService
+	Settings              *SettingsService
+	Notifications         *NotificationService
+	Logs                  *LogService
+	Allocator             SpotAllocator // Picks the spot number of reservations made without one
+
+	// OnRelease is called whenever a spot number becomes free again on a date and slot
+	OnRelease func(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string, spotNumber int)
 }
 
 func NewReservationService() *ReservationService {
@@ -23,12 +39,30 @@ func NewReservationService() *ReservationService {
 		ReservationCollection: db.GetCollection("reservation"),
 		ParkingSpotCollection: db.GetCollection("parkingSpot"),
 		UserCollection:        db.GetCollection("user"),
+		FoodtruckCollection:   db.GetCollection("foodtruck"),
+		SeriesCollection:      db.GetCollection("reservationSeries"),
+		LotteryCollection:     db.GetCollection("lotteryRound"),
+		HoldCollection:        db.GetCollection("reservationHold"),
+		WaitlistCollection:    db.GetCollection("waitlist"),
+		Occupancy:             NewOccupancyService(),
+		Closures:              NewClosureService(),
+		Locations:             NewLocationService(),
+		Settings:              NewSettingsService(),
+		Notifications:         NewNotificationService(),
+		Logs:                  NewLogService(),
+		Allocator:             LoadSpotAllocator(),
 	}
 }
 
-// GetAllReservations retrieves all reservations (admin use case).
-func (s *ReservationService) GetAllReservations(ctx context.Context) ([]model.Reservation, error) {
-	cursor, err := s.ReservationCollection.Find(ctx, bson.D{})
+// GetAllReservations retrieves all reservations at the given locations, every location when none are given
+// (admin use case).
+func (s *ReservationService) GetAllReservations(ctx context.Context, locationIDs []primitive.ObjectID) ([]model.Reservation, error) {
+	filter := bson.M{}
+	if len(locationIDs) > 0 {
+		filter["location_id"] = bson.M{"$in": locationIDs}
+	}
+
+	cursor, err := s.ReservationCollection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +90,8 @@ func (s *ReservationService) GetAllReservations(ctx context.Context) ([]model.Re
 	return reservations, nil
 }
 
-// GetAllUserReservations retrieves all reservations for a user (without userID and foodTruckID).
+// GetAllUserReservations retrieves all reservations for a user (without userID, foodTruckID, the food truck name and
+// who changed their status).
 func (s *ReservationService) GetAllUserReservations(ctx context.Context) ([]model.Reservation, error) {
 	cursor, err := s.ReservationCollection.Find(ctx, bson.D{})
 	if err != nil {
@@ -82,6 +117,8 @@ func (s *ReservationService) GetAllUserReservations(ctx context.Context) ([]mode
 		// Remove sensitive fields for user view
 		reservations[i].UserID = primitive.NilObjectID
 		reservations[i].FoodTruckID = primitive.NilObjectID
+		reservations[i].FoodTruckName = ""
+		reservations[i].StatusHistory = nil
 
 		reservations[i].SpotNumber = reservation.SpotNumber
 	}
@@ -138,28 +175,27 @@ func (s *ReservationService) GetReservationByID(ctx context.Context, reservation
 	return &reservation, nil
 }
 
-// CreateReservation creates a new reservation with the ability to choose the spot number.
+// CreateReservation creates a new reservation with the ability to choose the spot number. Without a spot number,
+// one of the free spot numbers able to host the food truck is assigned by the allocator.
 func (s *ReservationService) CreateReservation(ctx context.Context, reservation *model.Reservation) error {
-	// Validate the reservation date: it should be in the future and not today
-	if reservation.Date.Before(time.Now().Add(time.Hour * 24)) {
-		return errors.New("cannot reserve a spot for a past date or today")
-	}
+	return s.createReservation(ctx, reservation, primitive.NilObjectID)
+}
 
-	// Ensure the food truck has not reserved a spot for the same week
-	existingFilter := bson.M{
-		"food_truck_id": reservation.FoodTruckID,
-		"date": bson.M{
-			"$gte": time.Now().Add(-7 * 24 * time.Hour),
-		},
+// createReservation creates a reservation, drawnRoundID being the lottery round booking it during its draw, if any
+func (s *ReservationService) createReservation(ctx context.Context, reservation *model.Reservation, drawnRoundID primitive.ObjectID) error {
+	reservation.Date = utils.TruncateToDay(reservation.Date)
+
+	// Ensure the user is allowed to book
+	if err := s.checkBookingBan(ctx, reservation.UserID); err != nil {
+		return err
 	}
 
-	count, err := s.ReservationCollection.CountDocuments(ctx, existingFilter)
+	// Ensure the food truck exists, may be booked for by the user and is in good standing
+	foodtruck, err := s.bookableFoodtruck(ctx, reservation.FoodTruckID, reservation.UserID)
 	if err != nil {
-		return errors.New("failed to check existing reservations")
-	}
-	if count > 0 {
-		return errors.New("food truck already has a reservation for this week")
+		return err
 	}
+	reservation.FoodTruckName = foodtruck.Name
 
 	// Ensure the SpotID is in ObjectID format
 	spotID, err := primitive.ObjectIDFromHex(reservation.SpotID.Hex())
@@ -178,166 +214,301 @@ func (s *ReservationService) CreateReservation(ctx context.Context, reservation
 		return err
 	}
 
-	// Ensure the chosen spot number is available (i.e., not already reserved)
-	spotNumberAvailable := false
-	for _, num := range parkingSpot.SpotNumbers {
-		if num == reservation.SpotNumber {
-			spotNumberAvailable = true
-			break
-		}
-	}
-	if !spotNumberAvailable {
-		return fmt.Errorf("spot number %d is not available for reservation", reservation.SpotNumber)
+	// Ensure the parking spot is open on the weekday of the requested date
+	if parkingSpot.Day != reservation.Date.Weekday().String() {
+		return fmt.Errorf("spot is not available on %s", reservation.Date.Weekday())
 	}
 
-	// Ensure the max capacity is not exceeded (calculate used spots for the day)
-	spotCount, err := s.ReservationCollection.CountDocuments(ctx, bson.M{
-		"spot_id": spotID,
-		"date":    reservation.Date,
-	})
+	// Ensure the location of the parking spot opens on that weekday
+	location, err := s.Locations.GetLocation(ctx, parkingSpot.LocationID)
 	if err != nil {
-		return errors.New("failed to check spot reservations")
+		return err
 	}
-
-	// Decrement the available capacity (convert spotCount to int for comparison)
-	if int(spotCount) >= parkingSpot.MaxCapacity {
-		return errors.New("no available spots for this day")
+	if !isOpeningDay(location, reservation.Date) {
+		return fmt.Errorf("spot is not available on %s at %s", reservation.Date.Weekday(), location.Name)
 	}
+	reservation.LocationID = location.ID
 
-	// Insert the reservation into the reservation collection
-	reservation.CreatedAt = time.Now()
-	result, err := s.ReservationCollection.InsertOne(ctx, reservation)
-	if err != nil {
-		return err
+	// Validate the reservation date: it cannot be in the past at the location
+	if reservation.Date.Before(locationToday(location, time.Now())) {
+		return errors.New("cannot reserve a spot for a past date")
 	}
 
-	// Ensure the reservation ID is populated
-	reservation.ID = result.InsertedID.(primitive.ObjectID)
-
-	// Update the parking spot's reserved count and mark the spot as reserved
-	update := bson.M{
-		"$inc": bson.M{
-			"reserved_count": 1,
-		},
-		"$push": bson.M{
-			"reserved_spots": reservation.SpotNumber, // Track which spots are reserved for the given day
-		},
+	// Ensure the booking policy allows the date: lead time, horizon, blackout days and quotas
+	if err := s.checkBookingPolicy(ctx, reservation, location); err != nil {
+		return err
 	}
 
-	// Update the parking spot with the new reservation details
-	_, err = s.ParkingSpotCollection.UpdateOne(ctx, bson.M{"_id": spotID}, update)
-	if err != nil {
+	// Ensure the date is not waiting for a lottery draw
+	if err := s.checkLotteryWindow(ctx, location.ID, reservation.Date, drawnRoundID); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (s *ReservationService) UpdateReservation(ctx context.Context, reservationID primitive.ObjectID, updateData bson.M, userID primitive.ObjectID) error {
-	// Only filter by reservation ID
-	filter := bson.M{"_id": reservationID}
+	// Ensure the chosen spot number exists in the parking spot layout
+	autoAssign := reservation.SpotNumber == 0
+	if !autoAssign && !containsInt(parkingSpot.SpotNumbers, reservation.SpotNumber) {
+		return fmt.Errorf("spot number %d is not available for reservation", reservation.SpotNumber)
+	}
 
-	var reservation model.Reservation
-	err := s.ReservationCollection.FindOne(ctx, filter).Decode(&reservation)
+	// Ensure the booked slot exists for the day of the parking spot
+	slot, err := resolveSlot(&parkingSpot, reservation.SlotID)
 	if err != nil {
-		return errors.New("reservation not found")
+		return err
 	}
 
-	// If spot number is changing, update the parking spots accordingly
-	if updateData["spot_number"] != nil && updateData["spot_number"] != reservation.SpotNumber {
-		// Release the old spot
-		updateOldSpot := bson.M{"$pull": bson.M{"reserved_spots": reservation.SpotNumber}, "$inc": bson.M{"reserved_count": -1}}
-		_, err := s.ParkingSpotCollection.UpdateOne(ctx, bson.M{"_id": reservation.SpotID}, updateOldSpot)
+	if autoAssign {
+		// A spot number held by the user is already claimed, otherwise pick and claim one of the free spot
+		// numbers able to host the food truck
+		held, err := s.consumeHold(ctx, reservation)
 		if err != nil {
 			return err
 		}
+		if !held {
+			if err := s.assignSpotNumber(ctx, &parkingSpot, location, slot, reservation, foodtruck); err != nil {
+				return err
+			}
+		}
+	} else {
+		// Ensure the spot number can host the food truck
+		if err := checkSpotCompatibility(location, reservation.SpotNumber, foodtruck.Requirements); err != nil {
+			return err
+		}
 
-		// Update the reservation with the new spot number
-		newSpotNumber := updateData["spot_number"].(int)
-		updateData["spot_number"] = newSpotNumber // Ensure reservation's spot number is updated
+		// Ensure the lot is not closed on that date for the chosen spot number
+		if err := s.Closures.CheckOpen(ctx, reservation.LocationID, reservation.Date, reservation.SpotNumber); err != nil {
+			return err
+		}
 
-		// Add the new spot number to the reserved spots
-		updateNewSpot := bson.M{"$push": bson.M{"reserved_spots": newSpotNumber}, "$inc": bson.M{"reserved_count": 1}}
-		_, err = s.ParkingSpotCollection.UpdateOne(ctx, bson.M{"_id": reservation.SpotID}, updateNewSpot)
+		// A spot number held by the user is already claimed
+		held, err := s.consumeHold(ctx, reservation)
 		if err != nil {
-			return errors.New("failed to update parking spot status")
+			return err
+		}
+
+		// Atomically claim the spot number for the date and slot; concurrent bookings of the same spot get a conflict
+		if !held {
+			if err := s.Occupancy.Reserve(ctx, spotID, reservation.Date, reservation.SlotID, reservation.SpotNumber, slotCapacity(&parkingSpot, slot)); err != nil {
+				return err
+			}
 		}
 	}
 
-	// Update reservation with new data
-	update := bson.M{"$set": updateData}
-	_, err = s.ReservationCollection.UpdateOne(ctx, filter, update)
+	// Insert the reservation into the reservation collection. Locations requiring approval keep it pending, holding
+	// the spot number, until an admin reviews it; bookings made by admins need no review.
+	reservation.CreatedAt = time.Now()
+	reservation.Status = model.StatusConfirmed
+	reservation.ApprovalDeadline = nil
+	reservation.LateCancellation = false
+	if location.RequiresApproval && s.userRole(ctx, reservation.UserID) != "admin" {
+		deadline := approvalDeadline(location, reservation.Date, reservation.CreatedAt)
+		reservation.Status = model.StatusPending
+		reservation.ApprovalDeadline = &deadline
+	}
+	reservation.StatusHistory = []model.StatusChange{{Status: reservation.Status, At: reservation.CreatedAt, By: reservation.UserID}}
+	result, err := s.ReservationCollection.InsertOne(ctx, reservation)
 	if err != nil {
-		return errors.New("failed to update reservation")
+		// Give the claimed spot number back so it does not stay blocked
+		_ = s.Occupancy.Release(ctx, spotID, reservation.Date, reservation.SlotID, reservation.SpotNumber)
+		return err
 	}
 
+	// Ensure the reservation ID is populated
+	reservation.ID = result.InsertedID.(primitive.ObjectID)
+
 	return nil
 }
 
-func (s *ReservationService) DeleteReservation(ctx context.Context, reservationID primitive.ObjectID, userID primitive.ObjectID) error {
-	// Find the reservation by ID (and optionally user ID if provided)
-	filter := bson.M{"_id": reservationID}
-	if !userID.IsZero() {
-		filter["user_id"] = userID
+// UpdateReservation moves a reservation of the user, scoped by user ID when given, to another date, slot or spot
+// number. A new date or slot is checked like a new reservation, on the parking spot of the location open on the new
+// weekday, and claimed before the former one is released; a new spot number on the same date and slot is swapped.
+func (s *ReservationService) UpdateReservation(ctx context.Context, reservationID primitive.ObjectID, update *model.ReservationUpdate, userID primitive.ObjectID) (*model.Reservation, error) {
+	reservation, err := s.GetReservationByID(ctx, reservationID, userID)
+	if err != nil {
+		return nil, errors.New("reservation not found")
 	}
 
-	var reservation model.Reservation
-	err := s.ReservationCollection.FindOne(ctx, filter).Decode(&reservation)
-	if err != nil {
-		return errors.New("reservation not found")
+	// Only reservations still to come can be moved
+	status := reservationStatus(reservation)
+	if status != model.StatusConfirmed && status != model.StatusPending {
+		return nil, fmt.Errorf("cannot update a reservation that is %s", status)
 	}
 
-	// Find the associated parking spot
-	parkingSpot := model.ParkingSpot{}
-	err = s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": reservation.SpotID}).Decode(&parkingSpot)
-	if err != nil {
-		return errors.New("parking spot not found")
+	target := *reservation
+	if update.Date != nil {
+		target.Date = utils.TruncateToDay(*update.Date)
+	}
+	if update.SlotID != nil {
+		target.SlotID = *update.SlotID
+	}
+	if update.SpotNumber != nil {
+		if *update.SpotNumber <= 0 {
+			return nil, errors.New("spot_number must be positive")
+		}
+		target.SpotNumber = *update.SpotNumber
 	}
 
-	// Decrease the reserved count for the parking spot
-	updateSpot := bson.M{"$pull": bson.M{"reserved_spots": reservation.SpotNumber}, "$inc": bson.M{"reserved_count": -1}} // Decrement reserved count and remove spot number
-	_, err = s.ParkingSpotCollection.UpdateOne(ctx, bson.M{"_id": reservation.SpotID}, updateSpot)
+	dateChanged := !target.Date.Equal(reservation.Date) || target.SlotID != reservation.SlotID
+	if !dateChanged && target.SpotNumber == reservation.SpotNumber {
+		return reservation, nil
+	}
+
+	if dateChanged {
+		err = s.rebookReservation(ctx, reservation, &target, userID)
+	} else {
+		err = s.moveSpotNumber(ctx, reservation, target.SpotNumber)
+	}
 	if err != nil {
-		return errors.New("failed to update parking spot capacity")
+		return nil, err
 	}
 
-	// Delete the reservation
-	_, err = s.ReservationCollection.DeleteOne(ctx, filter)
+	// The previous spot number is free again
+	s.notifyRelease(ctx, reservation.SpotID, reservation.Date, reservation.SlotID, reservation.SpotNumber)
+
+	return &target, nil
+}
+
+// moveSpotNumber swaps the spot number of a reservation for another one free on the same date and slot
+func (s *ReservationService) moveSpotNumber(ctx context.Context, reservation *model.Reservation, spotNumber int) error {
+	var parkingSpot model.ParkingSpot
+	if err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": reservation.SpotID}).Decode(&parkingSpot); err != nil {
+		return errors.New("spot is not available")
+	}
+	if !containsInt(parkingSpot.SpotNumbers, spotNumber) {
+		return fmt.Errorf("spot number %d is not available for reservation", spotNumber)
+	}
+
+	// The new spot number must not be closed on the reserved date
+	if err := s.Closures.CheckOpen(ctx, reservation.LocationID, reservation.Date, spotNumber); err != nil {
+		return err
+	}
+
+	// The new spot number must be able to host the food truck
+	location, err := s.Locations.GetLocation(ctx, reservation.LocationID)
+	if err != nil {
+		return err
+	}
+	requirements, err := truckRequirements(ctx, s.FoodtruckCollection, reservation.FoodTruckID)
 	if err != nil {
-		return errors.New("failed to delete reservation")
+		return err
+	}
+	if err := checkSpotCompatibility(location, spotNumber, requirements); err != nil {
+		return err
 	}
 
+	// Swap the old spot number for the new one on the same date and slot
+	if err := s.Occupancy.Move(ctx, reservation.SpotID, reservation.Date, reservation.SlotID, reservation.SpotNumber, spotNumber); err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": reservation.ID, "spot_number": reservation.SpotNumber}
+	result, err := s.ReservationCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"spot_number": spotNumber}})
+	if err != nil || result.MatchedCount == 0 {
+		_ = s.Occupancy.Move(ctx, reservation.SpotID, reservation.Date, reservation.SlotID, spotNumber, reservation.SpotNumber)
+		return errors.New("failed to update reservation")
+	}
 	return nil
 }
 
-func (s *ReservationService) AdminDeleteReservation(ctx context.Context, reservationID primitive.ObjectID) error {
-	// Find the reservation by ID
-	filter := bson.M{"_id": reservationID}
-	var reservation model.Reservation
-	err := s.ReservationCollection.FindOne(ctx, filter).Decode(&reservation)
+// rebookReservation moves a reservation to the date and slot of target, running every check of a new reservation.
+// The reservation itself is left out of the quotas, and the new spot number is claimed before the old one is freed.
+// At a location requiring approval, a move made by anyone but an admin waits for a new review.
+func (s *ReservationService) rebookReservation(ctx context.Context, reservation *model.Reservation, target *model.Reservation, userID primitive.ObjectID) error {
+	// Each weekday of a location has its own parking spot
+	var parkingSpot model.ParkingSpot
+	err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"location_id": reservation.LocationID, "day_of_week": target.Date.Weekday().String()}).Decode(&parkingSpot)
 	if err != nil {
-		return errors.New("reservation not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("spot is not available on %s", target.Date.Weekday())
+		}
+		return err
 	}
+	target.SpotID = parkingSpot.ID
 
-	// Find the associated parking spot
-	parkingSpot := model.ParkingSpot{}
-	err = s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": reservation.SpotID}).Decode(&parkingSpot)
+	violations, err := s.reservationViolations(ctx, target)
 	if err != nil {
-		return errors.New("parking spot not found")
+		return err
+	}
+	if len(violations) > 0 {
+		return violations[0]
 	}
 
-	// Decrease the reserved count for the parking spot
-	updateSpot := bson.M{"$pull": bson.M{"reserved_spots": reservation.SpotNumber}, "$inc": bson.M{"reserved_count": -1}}
-	_, err = s.ParkingSpotCollection.UpdateOne(ctx, bson.M{"_id": reservation.SpotID}, updateSpot)
+	slot, err := resolveSlot(&parkingSpot, target.SlotID)
 	if err != nil {
-		return errors.New("failed to update parking spot capacity")
+		return err
 	}
 
-	// Delete the reservation
-	_, err = s.ReservationCollection.DeleteOne(ctx, filter)
+	// A spot number held by the user is already claimed
+	held, err := s.consumeHold(ctx, target)
 	if err != nil {
-		return errors.New("failed to delete reservation")
+		return err
+	}
+	if !held {
+		if err := s.Occupancy.Reserve(ctx, parkingSpot.ID, target.Date, target.SlotID, target.SpotNumber, slotCapacity(&parkingSpot, slot)); err != nil {
+			return err
+		}
+	}
+
+	fields := bson.M{
+		"spot_id":     target.SpotID,
+		"date":        target.Date,
+		"slot_id":     target.SlotID,
+		"spot_number": target.SpotNumber,
+	}
+	update := bson.M{"$set": fields}
+	location, err := s.Locations.GetLocation(ctx, reservation.LocationID)
+	if err != nil {
+		_ = s.Occupancy.Release(ctx, target.SpotID, target.Date, target.SlotID, target.SpotNumber)
+		return err
+	}
+	if location.RequiresApproval && !userID.IsZero() && s.userRole(ctx, userID) != "admin" {
+		now := time.Now()
+		deadline := approvalDeadline(location, target.Date, now)
+		target.Status = model.StatusPending
+		target.ApprovalDeadline = &deadline
+		fields["status"] = target.Status
+		fields["approval_deadline"] = deadline
+		if reservationStatus(reservation) != model.StatusPending {
+			change := model.StatusChange{Status: model.StatusPending, At: now, By: userID, Reason: "moved, waiting for approval"}
+			target.StatusHistory = append(target.StatusHistory, change)
+			update["$push"] = bson.M{"status_history": change}
+		}
+	}
+
+	// Only update the reservation if it was not changed meanwhile
+	filter := bson.M{"_id": reservation.ID, "spot_id": reservation.SpotID, "date": reservation.Date, "spot_number": reservation.SpotNumber, "status": activeStatusFilter()}
+	result, err := s.ReservationCollection.UpdateOne(ctx, filter, update)
+	if err != nil || result.MatchedCount == 0 {
+		_ = s.Occupancy.Release(ctx, target.SpotID, target.Date, target.SlotID, target.SpotNumber)
+		return errors.New("failed to update reservation")
+	}
+
+	return s.releaseReservation(ctx, reservation)
+}
+
+// DeleteReservation cancels a reservation of the user under the cancellation policy. The reservation is kept
+// for history and its spot number is released.
+func (s *ReservationService) DeleteReservation(ctx context.Context, reservationID primitive.ObjectID, userID primitive.ObjectID) (*model.CancellationResult, error) {
+	return s.CancelReservation(ctx, reservationID, userID, "")
+}
+
+// AdminDeleteReservation cancels any reservation on behalf of an admin.
+func (s *ReservationService) AdminDeleteReservation(ctx context.Context, reservationID primitive.ObjectID, adminID primitive.ObjectID) error {
+	_, err := s.ChangeReservationStatus(ctx, reservationID, primitive.NilObjectID, adminID, model.StatusCancelled, "cancelled by an admin")
+	return err
+}
+
+// releaseReservation frees the spot number held by a reservation on its date and slot
+func (s *ReservationService) releaseReservation(ctx context.Context, reservation *model.Reservation) error {
+	if err := s.Occupancy.Release(ctx, reservation.SpotID, reservation.Date, reservation.SlotID, reservation.SpotNumber); err != nil {
+		return errors.New("failed to update parking spot capacity")
 	}
 
 	return nil
 }
+
+// notifyRelease lets the OnRelease listener react to a freed spot number
+func (s *ReservationService) notifyRelease(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string, spotNumber int) {
+	if s.OnRelease != nil {
+		s.OnRelease(ctx, spotID, date, slotID, spotNumber)
+	}
+}
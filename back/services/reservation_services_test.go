@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestDatabase connects to the MongoDB given by MONGODB_TEST_URI and returns a throwaway database.
+// Tests needing a real database are skipped when the variable is not set.
+func newTestDatabase(t *testing.T) *mongo.Database {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set, skipping MongoDB integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+
+	database := client.Database(fmt.Sprintf("hooly_test_%s", primitive.NewObjectID().Hex()))
+	t.Cleanup(func() {
+		_ = database.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+
+	return database
+}
+
+// newTestReservationService wires a ReservationService on the given database
+func newTestReservationService(t *testing.T, database *mongo.Database) *ReservationService {
+	occupancy := &OccupancyService{
+		OccupancyCollection:   database.Collection("spotOccupancy"),
+		ReservationCollection: database.Collection("reservation"),
+		ParkingSpotCollection: database.Collection("parkingSpot"),
+	}
+	if err := occupancy.EnsureIndexes(context.Background()); err != nil {
+		t.Fatalf("failed to create indexes: %v", err)
+	}
+
+	// Every test parking spot belongs to the default location, open every day
+	locations := &LocationService{
+		LocationCollection:    database.Collection("location"),
+		ParkingSpotCollection: database.Collection("parkingSpot"),
+		UserCollection:        database.Collection("user"),
+	}
+	location := &model.Location{Name: "Test lot", OpeningDays: []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}}
+	if err := locations.CreateLocation(context.Background(), location); err != nil {
+		t.Fatalf("failed to create location: %v", err)
+	}
+
+	return &ReservationService{
+		ReservationCollection: database.Collection("reservation"),
+		ParkingSpotCollection: database.Collection("parkingSpot"),
+		UserCollection:        database.Collection("user"),
+		FoodtruckCollection:   database.Collection("foodtruck"),
+		LotteryCollection:     database.Collection("lotteryRound"),
+		HoldCollection:        database.Collection("reservationHold"),
+		WaitlistCollection:    database.Collection("waitlist"),
+		SeriesCollection:      database.Collection("reservationSeries"),
+		Occupancy:             occupancy,
+		Closures:              &ClosureService{ClosureCollection: database.Collection("closure")},
+		Locations:             locations,
+		Settings:              &SettingsService{SettingsCollection: database.Collection("settings")},
+		Notifications:         &NotificationService{NotificationCollection: database.Collection("notification")},
+		Logs:                  &LogService{LogCollection: database.Collection("log")},
+	}
+}
+
+// insertTestParkingSpot stores a parking spot open on the weekday of date
+func insertTestParkingSpot(t *testing.T, service *ReservationService, date time.Time, spotNumbers []int) primitive.ObjectID {
+	location, err := service.Locations.GetLocation(context.Background(), primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to fetch location: %v", err)
+	}
+
+	spot := model.ParkingSpot{
+		ID:          primitive.NewObjectID(),
+		LocationID:  location.ID,
+		Day:         date.Weekday().String(),
+		MaxCapacity: len(spotNumbers),
+		SpotNumbers: spotNumbers,
+	}
+	if _, err := service.ParkingSpotCollection.InsertOne(context.Background(), spot); err != nil {
+		t.Fatalf("failed to insert parking spot: %v", err)
+	}
+	return spot.ID
+}
+
+// insertTestFoodtruck stores a food truck owned by a new user and returns both IDs
+func insertTestFoodtruck(t *testing.T, service *ReservationService) (primitive.ObjectID, primitive.ObjectID) {
+	foodtruck := model.Foodtruck{ID: primitive.NewObjectID(), Name: "Test truck", UserID: primitive.NewObjectID()}
+	if _, err := service.FoodtruckCollection.InsertOne(context.Background(), foodtruck); err != nil {
+		t.Fatalf("failed to insert food truck: %v", err)
+	}
+	return foodtruck.ID, foodtruck.UserID
+}
+
+// bookTestReservations books count reservations a week apart from three days ahead, each on its own spot number of
+// a shared parking spot, which keeps one spot number free, and for the food truck of a new user
+func bookTestReservations(t *testing.T, service *ReservationService, count int) []*model.Reservation {
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotNumbers := []int{}
+	for spotNumber := 1; spotNumber <= count+1; spotNumber++ {
+		spotNumbers = append(spotNumbers, spotNumber)
+	}
+	spotID := insertTestParkingSpot(t, service, date, spotNumbers)
+
+	reservations := make([]*model.Reservation, 0, count)
+	for i := 0; i < count; i++ {
+		foodTruckID, userID := insertTestFoodtruck(t, service)
+		if _, err := service.UserCollection.InsertOne(ctx, model.User{ID: userID, Role: "user"}); err != nil {
+			t.Fatalf("failed to insert user: %v", err)
+		}
+		reservation := &model.Reservation{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: i + 1, Date: date.AddDate(0, 0, 7*i)}
+		if err := service.CreateReservation(ctx, reservation); err != nil {
+			t.Fatalf("failed to create reservation: %v", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations
+}
+
+func TestCreateReservationConcurrentSameSpotNumber(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2, 3, 4, 5, 6, 7})
+
+	const attempts = 30
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		foodTruckID, userID := insertTestFoodtruck(t, service)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- service.CreateReservation(context.Background(), &model.Reservation{
+				SpotID:      spotID,
+				FoodTruckID: foodTruckID,
+				UserID:      userID,
+				SpotNumber:  3,
+				Date:        date,
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrSpotConflict):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one successful booking, got %d", succeeded)
+	}
+
+	count, err := service.ReservationCollection.CountDocuments(context.Background(), bson.M{"spot_id": spotID, "date": date, "spot_number": 3})
+	if err != nil {
+		t.Fatalf("failed to count reservations: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected one stored reservation for spot 3, got %d", count)
+	}
+}
+
+func TestCreateReservationConcurrentCapacity(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2, 3, 4, 5, 6})
+
+	// Every spot number is requested several times in parallel
+	const attempts = 24
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		foodTruckID, userID := insertTestFoodtruck(t, service)
+		wg.Add(1)
+		go func(spotNumber int) {
+			defer wg.Done()
+			errs <- service.CreateReservation(context.Background(), &model.Reservation{
+				SpotID:      spotID,
+				FoodTruckID: foodTruckID,
+				UserID:      userID,
+				SpotNumber:  spotNumber,
+				Date:        date,
+			})
+		}(i%6 + 1)
+	}
+	wg.Wait()
+	close(errs)
+
+	occupancy, err := service.Occupancy.GetOccupancy(context.Background(), spotID, date, "")
+	if err != nil {
+		t.Fatalf("failed to read occupancy: %v", err)
+	}
+	if occupancy.ReservedCount != 6 || len(occupancy.TakenSpots) != 6 {
+		t.Fatalf("expected 6 taken spots, got count=%d spots=%v", occupancy.ReservedCount, occupancy.TakenSpots)
+	}
+
+	// Each spot number was requested, so a booking may only lose to another one on the same number
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrSpotConflict):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 6 {
+		t.Fatalf("expected 6 successful bookings, got %d", succeeded)
+	}
+
+	for spotNumber := 1; spotNumber <= 6; spotNumber++ {
+		count, err := service.ReservationCollection.CountDocuments(context.Background(), bson.M{"spot_id": spotID, "date": date, "spot_number": spotNumber})
+		if err != nil {
+			t.Fatalf("failed to count reservations: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("spot number %d allocated %d times", spotNumber, count)
+		}
+	}
+}
+
+func TestReserveConcurrentFirstBookings(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	spotID := primitive.NewObjectID()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+
+	// Every booking races to create the occupancy document of the date
+	const bookings = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, bookings)
+	for spotNumber := 1; spotNumber <= bookings; spotNumber++ {
+		wg.Add(1)
+		go func(spotNumber int) {
+			defer wg.Done()
+			errs <- service.Occupancy.Reserve(context.Background(), spotID, date, "", spotNumber, bookings)
+		}(spotNumber)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected every free spot number to be booked, got %v", err)
+		}
+	}
+}
+
+func TestUpdateReservationRebooksTheNewDate(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	ctx := context.Background()
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2})
+
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	reservation := &model.Reservation{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 1, Date: date}
+	if err := service.CreateReservation(ctx, reservation); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+
+	newDate := date.AddDate(0, 0, 7)
+	spotNumber := 2
+	update := &model.ReservationUpdate{Date: &newDate, SpotNumber: &spotNumber}
+	if _, err := service.UpdateReservation(ctx, reservation.ID, update, primitive.NewObjectID()); err == nil {
+		t.Fatal("expected another user not to update the reservation")
+	}
+
+	updated, err := service.UpdateReservation(ctx, reservation.ID, update, reservation.UserID)
+	if err != nil {
+		t.Fatalf("failed to update reservation: %v", err)
+	}
+	if !updated.Date.Equal(newDate) || updated.SpotNumber != 2 {
+		t.Fatalf("expected the reservation to move to spot 2 on %s, got spot %d on %s", newDate, updated.SpotNumber, updated.Date)
+	}
+
+	before, err := service.Occupancy.GetOccupancy(ctx, spotID, date, "")
+	if err != nil {
+		t.Fatalf("failed to read occupancy: %v", err)
+	}
+	after, err := service.Occupancy.GetOccupancy(ctx, spotID, newDate, "")
+	if err != nil {
+		t.Fatalf("failed to read occupancy: %v", err)
+	}
+	if before.ReservedCount != 0 || after.ReservedCount != 1 || after.TakenSpots[0] != 2 {
+		t.Fatalf("expected the former date to be freed and spot 2 taken on the new one, got %+v and %+v", before, after)
+	}
+}
+
+func TestGetAllUserReservationsIsAnonymous(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	bookTestReservations(t, service, 1)
+
+	reservations, err := service.GetAllUserReservations(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list reservations: %v", err)
+	}
+	if len(reservations) != 1 {
+		t.Fatalf("expected one reservation, got %d", len(reservations))
+	}
+	reservation := reservations[0]
+	if !reservation.UserID.IsZero() || !reservation.FoodTruckID.IsZero() || reservation.FoodTruckName != "" || len(reservation.StatusHistory) != 0 {
+		t.Fatalf("expected the reservation not to tell who booked it, got %+v", reservation)
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// statusTransitions lists, for each status, the statuses a reservation may move to
+var statusTransitions = map[string][]string{
+	model.StatusPending:   {model.StatusConfirmed, model.StatusCancelled, model.StatusRejected, model.StatusExpired},
+	model.StatusConfirmed: {model.StatusCheckedIn, model.StatusCancelled, model.StatusNoShow},
+	model.StatusCheckedIn: {model.StatusCompleted},
+	model.StatusCompleted: {},
+	model.StatusCancelled: {},
+	model.StatusNoShow:    {},
+	model.StatusRejected:  {},
+	model.StatusExpired:   {},
+}
+
+// InvalidTransitionError is returned when a status change is not allowed by the transition table
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change reservation status from %s to %s", e.From, e.To)
+}
+
+// CanTransition reports whether a reservation may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidStatus reports whether status is a known reservation status
+func IsValidStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// reservationStatus returns the status of a reservation, treating legacy reservations without one as confirmed
+func reservationStatus(reservation *model.Reservation) string {
+	if reservation.Status == "" {
+		return model.StatusConfirmed
+	}
+	return reservation.Status
+}
+
+// isReleasedStatus reports whether a status no longer holds its spot number
+func isReleasedStatus(status string) bool {
+	for _, released := range model.ReleasedStatuses {
+		if status == released {
+			return true
+		}
+	}
+	return false
+}
+
+// activeStatusFilter matches reservations still holding their spot number, legacy ones included
+func activeStatusFilter() bson.M {
+	return bson.M{"$nin": model.ReleasedStatuses}
+}
+
+// ChangeReservationStatus moves a reservation to a new status following the transition table, scoped by user ID
+// when given. Entering a released status frees the spot number for the date.
+func (s *ReservationService) ChangeReservationStatus(ctx context.Context, reservationID primitive.ObjectID, userID primitive.ObjectID, actorID primitive.ObjectID, status string, reason string) (*model.Reservation, error) {
+	return s.changeReservationStatus(ctx, reservationID, userID, actorID, status, reason, nil)
+}
+
+// changeReservationStatus changes the status of a reservation like ChangeReservationStatus, setting fields in the
+// same update
+func (s *ReservationService) changeReservationStatus(ctx context.Context, reservationID primitive.ObjectID, userID primitive.ObjectID, actorID primitive.ObjectID, status string, reason string, fields bson.M) (*model.Reservation, error) {
+	if !IsValidStatus(status) {
+		return nil, fmt.Errorf("invalid reservation status %q", status)
+	}
+
+	filter := bson.M{"_id": reservationID}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	var reservation model.Reservation
+	if err := s.ReservationCollection.FindOne(ctx, filter).Decode(&reservation); err != nil {
+		return nil, errors.New("reservation not found")
+	}
+
+	current := reservationStatus(&reservation)
+	if !CanTransition(current, status) {
+		return nil, &InvalidTransitionError{From: current, To: status}
+	}
+
+	// Only apply the change if nobody moved the reservation in the meantime
+	filter["status"] = reservation.Status
+	if reservation.Status == "" {
+		filter["status"] = bson.M{"$exists": false}
+	}
+
+	change := model.StatusChange{Status: status, At: time.Now(), By: actorID, Reason: reason}
+	set := bson.M{"status": status}
+	for key, value := range fields {
+		set[key] = value
+	}
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"status_history": change},
+	}
+
+	result, err := s.ReservationCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, errors.New("failed to update reservation status")
+	}
+	if result.ModifiedCount == 0 {
+		return nil, errors.New("reservation status changed concurrently, please retry")
+	}
+
+	reservation.Status = status
+	reservation.StatusHistory = append(reservation.StatusHistory, change)
+	s.recordAttendance(ctx, &reservation, status)
+
+	// Free the spot number once the reservation stops holding it
+	if isReleasedStatus(status) && !isReleasedStatus(current) {
+		if err := s.releaseReservation(ctx, &reservation); err != nil {
+			return nil, err
+		}
+		s.notifyRelease(ctx, reservation.SpotID, reservation.Date, reservation.SlotID, reservation.SpotNumber)
+	}
+
+	return &reservation, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"gitlab.com/hooly2/back/model"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{model.StatusPending, model.StatusConfirmed, true},
+		{model.StatusPending, model.StatusCancelled, true},
+		{model.StatusPending, model.StatusCheckedIn, false},
+		{model.StatusPending, model.StatusRejected, true},
+		{model.StatusPending, model.StatusExpired, true},
+		{model.StatusConfirmed, model.StatusRejected, false},
+		{model.StatusExpired, model.StatusConfirmed, false},
+		{model.StatusConfirmed, model.StatusCheckedIn, true},
+		{model.StatusConfirmed, model.StatusCancelled, true},
+		{model.StatusConfirmed, model.StatusNoShow, true},
+		{model.StatusConfirmed, model.StatusCompleted, false},
+		{model.StatusCheckedIn, model.StatusCompleted, true},
+		{model.StatusCheckedIn, model.StatusCancelled, false},
+		{model.StatusCompleted, model.StatusCancelled, false},
+		{model.StatusCancelled, model.StatusConfirmed, false},
+		{model.StatusNoShow, model.StatusCheckedIn, false},
+		{"unknown", model.StatusConfirmed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.expected {
+				t.Fatalf("CanTransition(%s, %s) = %v, expected %v", tt.from, tt.to, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReservationStatusDefaultsToConfirmed(t *testing.T) {
+	if status := reservationStatus(&model.Reservation{}); status != model.StatusConfirmed {
+		t.Fatalf("expected legacy reservation to be confirmed, got %s", status)
+	}
+}
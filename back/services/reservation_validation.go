@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// Names of the booking checks performed besides the booking policy rules
+const (
+	RuleDate          = "date"
+	RuleBookingBan    = "booking_ban"
+	RuleSpot          = "spot"
+	RuleOpeningDay    = "opening_day"
+	RuleSpotNumber    = "spot_number"
+	RuleSlot          = "slot"
+	RuleCompatibility = "compatibility"
+	RuleClosed        = "closed"
+	RuleSpotTaken     = "spot_taken"
+	RuleCapacity      = "capacity"
+	RuleLottery       = "lottery"
+	RuleFoodtruck     = "food_truck"
+)
+
+const (
+	// maxSuggestionDistance bounds how many days before and after the requested date are searched for suggestions
+	maxSuggestionDistance = 28
+	// maxSuggestedDates is how many valid dates are suggested at most
+	maxSuggestedDates = 3
+)
+
+// dateIndependentRules are the checks failing on every date alike, for which suggesting other dates is pointless
+var dateIndependentRules = []string{RuleBookingBan, RuleFoodtruck}
+
+// SuggestedDate is a date on which a reservation can be booked, along with the parking spot open on its weekday
+type SuggestedDate struct {
+	Date   time.Time          `json:"date"`
+	SpotID primitive.ObjectID `json:"spot_id"`
+}
+
+// ReservationValidation is the outcome of a dry run of CreateReservation
+type ReservationValidation struct {
+	Valid                bool               `json:"valid"`
+	Violations           []*PolicyViolation `json:"violations"`
+	SuggestedDates       []SuggestedDate    `json:"suggested_dates"`        // Nearest dates on which the same spot number can be booked
+	SuggestedSpotNumbers []int              `json:"suggested_spot_numbers"` // Free spot numbers suiting the food truck on the requested date
+}
+
+// ValidateReservation runs every check CreateReservation performs without booking anything. All violations are
+// reported at once; an invalid reservation comes with the nearest valid dates and the spot numbers still free.
+func (s *ReservationService) ValidateReservation(ctx context.Context, reservation *model.Reservation) (*ReservationValidation, error) {
+	reservation.Date = utils.TruncateToDay(reservation.Date)
+
+	violations, err := s.reservationViolations(ctx, reservation)
+	if err != nil {
+		return nil, err
+	}
+
+	validation := &ReservationValidation{
+		Valid:                len(violations) == 0,
+		Violations:           violations,
+		SuggestedDates:       []SuggestedDate{},
+		SuggestedSpotNumbers: []int{},
+	}
+	if validation.Valid {
+		return validation, nil
+	}
+
+	if validation.SuggestedSpotNumbers, err = s.suggestSpotNumbers(ctx, reservation); err != nil {
+		return nil, err
+	}
+	if !dateRelated(violations) {
+		return validation, nil
+	}
+	if validation.SuggestedDates, err = s.suggestDates(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	return validation, nil
+}
+
+// reservationViolations checks a reservation the way CreateReservation does, collecting every violation instead
+// of stopping at the first one. Checks depending on a missing parking spot or slot are skipped, and a reservation
+// without a spot number only needs one free spot number able to host the food truck.
+func (s *ReservationService) reservationViolations(ctx context.Context, reservation *model.Reservation) ([]*PolicyViolation, error) {
+	violations := []*PolicyViolation{}
+	violate := func(rule string, err error) {
+		violations = append(violations, &PolicyViolation{Rule: rule, Message: err.Error()})
+	}
+
+	// The location of the parking spot, when it exists, decides when days start
+	var location *model.Location
+	var parkingSpot model.ParkingSpot
+	spotErr := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": reservation.SpotID}).Decode(&parkingSpot)
+	if spotErr != nil && !errors.Is(spotErr, mongo.ErrNoDocuments) {
+		return nil, spotErr
+	}
+	if spotErr == nil {
+		found, err := s.Locations.GetLocation(ctx, parkingSpot.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		location = found
+	}
+
+	if reservation.Date.Before(locationToday(location, time.Now())) {
+		violate(RuleDate, errors.New("cannot reserve a spot for a past date"))
+	}
+	if err := s.checkBookingBan(ctx, reservation.UserID); err != nil {
+		violate(RuleBookingBan, err)
+	}
+	if _, err := s.bookableFoodtruck(ctx, reservation.FoodTruckID, reservation.UserID); err != nil {
+		if !errors.Is(err, ErrFoodtruckNotFound) && !errors.Is(err, ErrFoodtruckForbidden) && !errors.Is(err, ErrFoodtruckSuspended) {
+			return nil, err
+		}
+		violate(RuleFoodtruck, err)
+	}
+
+	policy, err := s.Settings.GetBookingPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request := &BookingRequest{Reservation: reservation, Role: s.userRole(ctx, reservation.UserID), Location: location, Now: time.Now()}
+	policyViolations, err := NewBookingPolicyChain(policy, s.ReservationCollection).Violations(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	violations = append(violations, policyViolations...)
+
+	if spotErr != nil {
+		violate(RuleSpot, errors.New("spot is not available"))
+		return violations, nil
+	}
+	if parkingSpot.Day != reservation.Date.Weekday().String() {
+		violate(RuleOpeningDay, fmt.Errorf("spot is not available on %s", reservation.Date.Weekday()))
+	} else if !isOpeningDay(location, reservation.Date) {
+		violate(RuleOpeningDay, fmt.Errorf("spot is not available on %s at %s", reservation.Date.Weekday(), location.Name))
+	}
+	if err := s.checkLotteryWindow(ctx, location.ID, reservation.Date, primitive.NilObjectID); err != nil {
+		if !errors.Is(err, ErrLotteryWindow) {
+			return nil, err
+		}
+		violate(RuleLottery, err)
+	}
+
+	autoAssign := reservation.SpotNumber == 0
+	if !autoAssign && !containsInt(parkingSpot.SpotNumbers, reservation.SpotNumber) {
+		violate(RuleSpotNumber, fmt.Errorf("spot number %d is not available for reservation", reservation.SpotNumber))
+	}
+
+	requirements, err := truckRequirements(ctx, s.FoodtruckCollection, reservation.FoodTruckID)
+	if err != nil {
+		return nil, err
+	}
+	// A spot number held by the user is already claimed for them
+	hold, err := s.activeHold(ctx, reservation)
+	if err != nil {
+		return nil, err
+	}
+
+	if autoAssign {
+		slot, err := resolveSlot(&parkingSpot, reservation.SlotID)
+		if err != nil {
+			violate(RuleSlot, err)
+			return violations, nil
+		}
+		if hold != nil {
+			return violations, nil
+		}
+		availability, err := s.spotAvailability(ctx, &parkingSpot, location, slot, reservation.Date, requirements)
+		if err != nil {
+			return nil, err
+		}
+		if len(availability.FreeSpots) == 0 {
+			violate(RuleCapacity, ErrSpotFull)
+		}
+		return violations, nil
+	}
+
+	if err := checkSpotCompatibility(location, reservation.SpotNumber, requirements); err != nil {
+		var incompatibleErr *IncompatibleSpotError
+		if !errors.As(err, &incompatibleErr) {
+			return nil, err
+		}
+		violations = append(violations, &PolicyViolation{
+			Rule:    RuleCompatibility,
+			Message: err.Error(),
+			Details: map[string]interface{}{"reasons": incompatibleErr.Reasons},
+		})
+	}
+
+	if err := s.Closures.CheckOpen(ctx, location.ID, reservation.Date, reservation.SpotNumber); err != nil {
+		if !errors.Is(err, ErrSpotClosed) {
+			return nil, err
+		}
+		violate(RuleClosed, err)
+	}
+
+	slot, err := resolveSlot(&parkingSpot, reservation.SlotID)
+	if err != nil {
+		violate(RuleSlot, err)
+		return violations, nil
+	}
+
+	if hold != nil {
+		return violations, nil
+	}
+
+	occupancy, err := s.Occupancy.GetOccupancy(ctx, parkingSpot.ID, reservation.Date, reservation.SlotID)
+	if err != nil {
+		return nil, err
+	}
+	if occupancy.ReservedCount >= slotCapacity(&parkingSpot, slot) {
+		violate(RuleCapacity, ErrSpotFull)
+	} else if containsInt(occupancy.TakenSpots, reservation.SpotNumber) {
+		violate(RuleSpotTaken, ErrSpotConflict)
+	}
+
+	return violations, nil
+}
+
+// suggestSpotNumbers lists the spot numbers free on the requested date and slot that can host the food truck
+func (s *ReservationService) suggestSpotNumbers(ctx context.Context, reservation *model.Reservation) ([]int, error) {
+	var parkingSpot model.ParkingSpot
+	if err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": reservation.SpotID}).Decode(&parkingSpot); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return []int{}, nil
+		}
+		return nil, err
+	}
+	slot, err := resolveSlot(&parkingSpot, reservation.SlotID)
+	if err != nil || parkingSpot.Day != reservation.Date.Weekday().String() {
+		return []int{}, nil
+	}
+
+	location, err := s.Locations.GetLocation(ctx, parkingSpot.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if !isOpeningDay(location, reservation.Date) {
+		return []int{}, nil
+	}
+	requirements, err := truckRequirements(ctx, s.FoodtruckCollection, reservation.FoodTruckID)
+	if err != nil {
+		return nil, err
+	}
+	availability, err := s.spotAvailability(ctx, &parkingSpot, location, slot, reservation.Date, requirements)
+	if err != nil {
+		return nil, err
+	}
+	return availability.FreeSpots, nil
+}
+
+// dateRelated reports whether booking on another date could avoid the violations, none of them failing on every
+// date alike
+func dateRelated(violations []*PolicyViolation) bool {
+	for _, violation := range violations {
+		for _, rule := range dateIndependentRules {
+			if violation.Rule == rule {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// suggestDates searches the dates around the requested one, nearest first, on which the same spot number and slot
+// of the location pass every check
+func (s *ReservationService) suggestDates(ctx context.Context, reservation *model.Reservation) ([]SuggestedDate, error) {
+	var requested model.ParkingSpot
+	if err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": reservation.SpotID}).Decode(&requested); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return []SuggestedDate{}, nil
+		}
+		return nil, err
+	}
+
+	// Each weekday has its own parking spot in the location
+	spotsByDay := make(map[string]primitive.ObjectID)
+	cursor, err := s.ParkingSpotCollection.Find(ctx, bson.M{"location_id": requested.LocationID})
+	if err != nil {
+		return nil, err
+	}
+	var spots []model.ParkingSpot
+	if err := cursor.All(ctx, &spots); err != nil {
+		return nil, err
+	}
+	for _, spot := range spots {
+		spotsByDay[spot.Day] = spot.ID
+	}
+
+	today, err := s.todayAt(ctx, requested.LocationID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	suggested := []SuggestedDate{}
+	for distance := 1; distance <= maxSuggestionDistance && len(suggested) < maxSuggestedDates; distance++ {
+		for _, date := range []time.Time{reservation.Date.AddDate(0, 0, -distance), reservation.Date.AddDate(0, 0, distance)} {
+			spotID, ok := spotsByDay[date.Weekday().String()]
+			if !ok || date.Before(today) || len(suggested) == maxSuggestedDates {
+				continue
+			}
+
+			candidate := *reservation
+			candidate.Date = date
+			candidate.SpotID = spotID
+			violations, err := s.reservationViolations(ctx, &candidate)
+			if err != nil {
+				return nil, err
+			}
+			if len(violations) == 0 {
+				suggested = append(suggested, SuggestedDate{Date: date, SpotID: spotID})
+			}
+		}
+	}
+
+	return suggested, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateReservationReportsTakenSpotWithSuggestions(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1, 2, 3})
+
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	if err := service.CreateReservation(context.Background(), &model.Reservation{
+		SpotID:      spotID,
+		FoodTruckID: foodTruckID,
+		UserID:      userID,
+		SpotNumber:  3,
+		Date:        date,
+	}); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+
+	foodTruckID, userID = insertTestFoodtruck(t, service)
+	validation, err := service.ValidateReservation(context.Background(), &model.Reservation{
+		SpotID:      spotID,
+		FoodTruckID: foodTruckID,
+		UserID:      userID,
+		SpotNumber:  3,
+		Date:        date,
+	})
+	if err != nil {
+		t.Fatalf("failed to validate reservation: %v", err)
+	}
+
+	if validation.Valid || len(validation.Violations) != 1 || validation.Violations[0].Rule != RuleSpotTaken {
+		t.Fatalf("expected a single spot_taken violation, got %+v", validation.Violations)
+	}
+	if !reflect.DeepEqual(validation.SuggestedSpotNumbers, []int{1, 2}) {
+		t.Fatalf("expected spots 1 and 2 to be suggested, got %v", validation.SuggestedSpotNumbers)
+	}
+	if len(validation.SuggestedDates) != maxSuggestedDates || !validation.SuggestedDates[0].Date.Equal(date.AddDate(0, 0, 7)) || validation.SuggestedDates[0].SpotID != spotID {
+		t.Fatalf("expected the same weekday of the following weeks to be suggested, got %v", validation.SuggestedDates)
+	}
+}
+
+func TestDateRelated(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		want  bool
+	}{
+		{"taken spot", []string{RuleSpotTaken}, true},
+		{"date checks only", []string{RuleLeadTime, RuleOpeningDay, RuleClosed}, true},
+		{"booking ban", []string{RuleSpotTaken, RuleBookingBan}, false},
+		{"foreign food truck", []string{RuleFoodtruck}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := []*PolicyViolation{}
+			for _, rule := range tt.rules {
+				violations = append(violations, &PolicyViolation{Rule: rule})
+			}
+			if got := dateRelated(violations); got != tt.want {
+				t.Fatalf("dateRelated() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReservationSkipsDatesForForeignFoodtruck(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1})
+
+	foodTruckID, _ := insertTestFoodtruck(t, service)
+	_, userID := insertTestFoodtruck(t, service)
+	validation, err := service.ValidateReservation(context.Background(), &model.Reservation{
+		SpotID:      spotID,
+		FoodTruckID: foodTruckID,
+		UserID:      userID,
+		SpotNumber:  1,
+		Date:        date,
+	})
+	if err != nil {
+		t.Fatalf("failed to validate reservation: %v", err)
+	}
+
+	if validation.Valid || len(validation.Violations) != 1 || validation.Violations[0].Rule != RuleFoodtruck {
+		t.Fatalf("expected a single food_truck violation, got %+v", validation.Violations)
+	}
+	if len(validation.SuggestedDates) != 0 {
+		t.Fatalf("expected no dates to be suggested, got %v", validation.SuggestedDates)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// cancellationPolicyID is the key of the cancellation policy in the settings collection
+const cancellationPolicyID = "cancellation_policy"
+
+// defaultCancellationPolicy applies until an admin saves a policy
+var defaultCancellationPolicy = model.CancellationPolicy{
+	ID:                       cancellationPolicyID,
+	CutoffHours:              48,
+	LateCancellationsAllowed: 2,
+	PeriodDays:               30,
+	BanDays:                  14,
+}
+
+// bookingPolicyID is the key of the booking policy in the settings collection
+const bookingPolicyID = "booking_policy"
+
+// defaultBookingPolicy applies until an admin saves a policy: bookings at least 24 hours ahead and the quotas of
+// the environment, one reservation per ISO week unless configured otherwise
+func defaultBookingPolicy() model.BookingPolicy {
+	policy := model.BookingPolicy{
+		ID:            bookingPolicyID,
+		LeadTimeHours: 24,
+		WeekStart:     time.Monday.String(),
+		WeeklyLimit:   1,
+		BlackoutDays:  []string{},
+		Exemptions:    map[string][]string{},
+	}
+
+	if value := os.Getenv("RESERVATION_WEEK_START"); value != "" {
+		if _, ok := utils.ParseWeekday(value); ok {
+			policy.WeekStart = value
+		} else {
+			log.Println("Invalid RESERVATION_WEEK_START, using Monday:", value)
+		}
+	}
+	policy.WeeklyLimit = envInt("RESERVATION_WEEKLY_QUOTA", policy.WeeklyLimit)
+	policy.MonthlyLimit = envInt("RESERVATION_MONTHLY_QUOTA", policy.MonthlyLimit)
+
+	return policy
+}
+
+// envInt reads a non-negative integer environment variable, falling back to def
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Invalid %s, using %d: %s", name, def, value)
+		return def
+	}
+	return parsed
+}
+
+// SettingsService stores the admin-configurable settings, one document per setting
+type SettingsService struct {
+	SettingsCollection *mongo.Collection
+}
+
+func NewSettingsService() *SettingsService {
+	return &SettingsService{
+		SettingsCollection: db.GetCollection("settings"),
+	}
+}
+
+// GetCancellationPolicy returns the current cancellation policy, or the default one if none was saved
+func (s *SettingsService) GetCancellationPolicy(ctx context.Context) (*model.CancellationPolicy, error) {
+	policy := defaultCancellationPolicy
+	err := s.SettingsCollection.FindOne(ctx, bson.M{"_id": cancellationPolicyID}).Decode(&policy)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to fetch cancellation policy: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// UpdateCancellationPolicy validates and saves the cancellation policy
+func (s *SettingsService) UpdateCancellationPolicy(ctx context.Context, policy *model.CancellationPolicy, adminID primitive.ObjectID) error {
+	if policy.CutoffHours < 0 || policy.LateCancellationsAllowed < 0 || policy.BanDays < 0 {
+		return errors.New("policy values cannot be negative")
+	}
+	if policy.PeriodDays <= 0 {
+		return errors.New("period_days must be positive")
+	}
+
+	policy.ID = cancellationPolicyID
+	policy.UpdatedAt = time.Now()
+	policy.UpdatedBy = adminID
+
+	_, err := s.SettingsCollection.ReplaceOne(ctx, bson.M{"_id": cancellationPolicyID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save cancellation policy: %v", err)
+	}
+
+	return nil
+}
+
+// GetBookingPolicy returns the current booking policy, or the default one if none was saved
+func (s *SettingsService) GetBookingPolicy(ctx context.Context) (*model.BookingPolicy, error) {
+	policy := defaultBookingPolicy()
+	err := s.SettingsCollection.FindOne(ctx, bson.M{"_id": bookingPolicyID}).Decode(&policy)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to fetch booking policy: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// UpdateBookingPolicy validates and saves the booking policy
+func (s *SettingsService) UpdateBookingPolicy(ctx context.Context, policy *model.BookingPolicy, adminID primitive.ObjectID) error {
+	if policy.WeekStart == "" {
+		policy.WeekStart = time.Monday.String()
+	}
+	if policy.BlackoutDays == nil {
+		policy.BlackoutDays = []string{}
+	}
+	if policy.Exemptions == nil {
+		policy.Exemptions = map[string][]string{}
+	}
+	if err := validateBookingPolicy(policy); err != nil {
+		return err
+	}
+
+	policy.ID = bookingPolicyID
+	policy.UpdatedAt = time.Now()
+	policy.UpdatedBy = adminID
+
+	_, err := s.SettingsCollection.ReplaceOne(ctx, bson.M{"_id": bookingPolicyID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save booking policy: %v", err)
+	}
+
+	return nil
+}
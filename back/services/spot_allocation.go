@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"log"
+	"math"
+	"os"
+	"time"
+)
+
+// Names of the spot allocation strategies
+const (
+	AllocateLowest    = "lowest"
+	AllocatePreferred = "preferred"
+	AllocateSpread    = "spread"
+	AllocateBestFit   = "best_fit"
+)
+
+const (
+	// maxAllocationAttempts bounds how many spot numbers are tried when concurrent bookings take the chosen ones
+	maxAllocationAttempts = 5
+	// unlimitedSurplusM is the spare room counted for a spot whose length or width is not limited
+	unlimitedSurplusM = 10.0
+)
+
+// AllocationRequest describes what a spot number is picked from for a reservation made without one
+type AllocationRequest struct {
+	Free         []int           // Spot numbers free on the date and slot that can host the truck, ascending
+	Taken        []int           // Spot numbers already booked on the date and slot
+	Location     *model.Location // Holds the attributes of the spot numbers
+	Requirements model.TruckRequirements
+	Preferred    []int // Spot numbers the truck prefers, most wanted first
+}
+
+// SpotAllocator picks the spot number of a reservation made without one. It returns false when none of the free
+// spot numbers suits it.
+type SpotAllocator interface {
+	Allocate(request *AllocationRequest) (int, bool)
+}
+
+// LowestNumberAllocator picks the lowest free spot number
+type LowestNumberAllocator struct{}
+
+func (LowestNumberAllocator) Allocate(request *AllocationRequest) (int, bool) {
+	if len(request.Free) == 0 {
+		return 0, false
+	}
+	return request.Free[0], true
+}
+
+// PreferredSpotsAllocator picks the first free spot number the truck prefers, deferring to Fallback otherwise
+type PreferredSpotsAllocator struct {
+	Fallback SpotAllocator
+}
+
+func (a PreferredSpotsAllocator) Allocate(request *AllocationRequest) (int, bool) {
+	for _, num := range request.Preferred {
+		if containsInt(request.Free, num) {
+			return num, true
+		}
+	}
+
+	if a.Fallback == nil {
+		return LowestNumberAllocator{}.Allocate(request)
+	}
+	return a.Fallback.Allocate(request)
+}
+
+// SpreadAllocator picks the free spot number furthest from the taken ones so that trucks are spread out
+type SpreadAllocator struct{}
+
+func (SpreadAllocator) Allocate(request *AllocationRequest) (int, bool) {
+	best, bestDistance := 0, -1
+	for _, num := range request.Free {
+		distance := math.MaxInt
+		for _, taken := range request.Taken {
+			if d := abs(num - taken); d < distance {
+				distance = d
+			}
+		}
+		if distance > bestDistance {
+			best, bestDistance = num, distance
+		}
+	}
+	return best, bestDistance >= 0
+}
+
+// BestFitAllocator picks the free spot number with the least room and amenities to spare for the truck, keeping
+// larger and better equipped spots for the trucks needing them
+type BestFitAllocator struct{}
+
+func (BestFitAllocator) Allocate(request *AllocationRequest) (int, bool) {
+	best, bestSurplus := 0, math.Inf(1)
+	for _, num := range request.Free {
+		if surplus := spotSurplus(spotAttributes(request.Location, num), request.Requirements); surplus < bestSurplus {
+			best, bestSurplus = num, surplus
+		}
+	}
+	return best, !math.IsInf(bestSurplus, 1)
+}
+
+// spotSurplus scores what a spot offers beyond the needs of a truck: spare meters, spare amps by tens and one
+// point per unneeded amenity
+func spotSurplus(spot model.SpotAttributes, requirements model.TruckRequirements) float64 {
+	surplus := 0.0
+	for _, room := range [][2]float64{{spot.LengthM, requirements.LengthM}, {spot.WidthM, requirements.WidthM}} {
+		if room[0] > 0 {
+			surplus += room[0] - room[1]
+		} else {
+			surplus += unlimitedSurplusM
+		}
+	}
+	surplus += float64(spot.ElectricityAmps-requirements.ElectricityAmps) / 10
+	if spot.Water && !requirements.Water {
+		surplus++
+	}
+	if spot.Covered && !requirements.Covered {
+		surplus++
+	}
+	return surplus
+}
+
+// validatePreferredSpots checks that the preferred spot numbers of a truck are positive and unique
+func validatePreferredSpots(spotNumbers []int) error {
+	seen := make(map[int]bool, len(spotNumbers))
+	for _, num := range spotNumbers {
+		if num <= 0 {
+			return fmt.Errorf("invalid preferred spot number %d", num)
+		}
+		if seen[num] {
+			return fmt.Errorf("duplicate preferred spot number %d", num)
+		}
+		seen[num] = true
+	}
+	return nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// NewSpotAllocator returns the allocator of a strategy; the preferred spots of the truck come first and the best
+// fit decides otherwise unless another strategy is named
+func NewSpotAllocator(strategy string) (SpotAllocator, error) {
+	switch strategy {
+	case AllocateLowest:
+		return LowestNumberAllocator{}, nil
+	case AllocatePreferred, "":
+		return PreferredSpotsAllocator{Fallback: BestFitAllocator{}}, nil
+	case AllocateSpread:
+		return SpreadAllocator{}, nil
+	case AllocateBestFit:
+		return BestFitAllocator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown spot allocation strategy %q", strategy)
+	}
+}
+
+// LoadSpotAllocator reads the allocation strategy from the SPOT_ALLOCATION_STRATEGY environment variable
+func LoadSpotAllocator() SpotAllocator {
+	allocator, err := NewSpotAllocator(os.Getenv("SPOT_ALLOCATION_STRATEGY"))
+	if err != nil {
+		log.Println("Invalid SPOT_ALLOCATION_STRATEGY, using preferred spots:", err)
+		allocator, _ = NewSpotAllocator(AllocatePreferred)
+	}
+	return allocator
+}
+
+// spotAvailability returns what is free and taken in a slot of a parking spot on a date, free spot numbers being
+// restricted to those open and able to host the truck
+func (s *ReservationService) spotAvailability(ctx context.Context, spot *model.ParkingSpot, location *model.Location, slot *model.TimeSlot, date time.Time, requirements model.TruckRequirements) (model.DayAvailability, error) {
+	occupancy, err := s.Occupancy.GetOccupancy(ctx, spot.ID, date, slotKey(slot))
+	if err != nil {
+		return model.DayAvailability{}, err
+	}
+	closures, err := s.Closures.ListClosures(ctx, location.ID, date, date)
+	if err != nil {
+		return model.DayAvailability{}, err
+	}
+
+	closed := closedSpotNumbers(closures, date, spot.SpotNumbers)
+	return slotAvailability(spot, location, date, slot, *occupancy, closed, &requirements), nil
+}
+
+// assignSpotNumber books a spot number picked by the allocator for a reservation made without one. Spot numbers
+// taken concurrently are skipped and another one is picked.
+func (s *ReservationService) assignSpotNumber(ctx context.Context, spot *model.ParkingSpot, location *model.Location, slot *model.TimeSlot, reservation *model.Reservation, foodtruck *model.Foodtruck) error {
+	allocator := s.Allocator
+	if allocator == nil {
+		allocator = LowestNumberAllocator{}
+	}
+
+	tried := []int{}
+	for attempt := 0; attempt < maxAllocationAttempts; attempt++ {
+		availability, err := s.spotAvailability(ctx, spot, location, slot, reservation.Date, foodtruck.Requirements)
+		if err != nil {
+			return err
+		}
+
+		free := []int{}
+		for _, num := range availability.FreeSpots {
+			if !containsInt(tried, num) {
+				free = append(free, num)
+			}
+		}
+		spotNumber, ok := allocator.Allocate(&AllocationRequest{
+			Free:         free,
+			Taken:        availability.TakenSpots,
+			Location:     location,
+			Requirements: foodtruck.Requirements,
+			Preferred:    foodtruck.PreferredSpots,
+		})
+		if !ok {
+			return ErrSpotFull
+		}
+
+		err = s.Occupancy.Reserve(ctx, spot.ID, reservation.Date, reservation.SlotID, spotNumber, slotCapacity(spot, slot))
+		if errors.Is(err, ErrSpotConflict) {
+			tried = append(tried, spotNumber)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		reservation.SpotNumber = spotNumber
+		return nil
+	}
+
+	return ErrSpotFull
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"gitlab.com/hooly2/back/model"
+	"testing"
+)
+
+func TestSpotAllocators(t *testing.T) {
+	location := &model.Location{Spots: []model.SpotAttributes{
+		{Number: 1, LengthM: 12, WidthM: 4, ElectricityAmps: 64, Water: true, Covered: true},
+		{Number: 2, LengthM: 7, WidthM: 3, ElectricityAmps: 16},
+		{Number: 3, LengthM: 8, WidthM: 3, ElectricityAmps: 32, Water: true},
+		{Number: 6, LengthM: 7, WidthM: 3, ElectricityAmps: 16},
+	}}
+
+	tests := []struct {
+		name      string
+		allocator SpotAllocator
+		request   AllocationRequest
+		want      int
+		wantOK    bool
+	}{
+		{"lowest number", LowestNumberAllocator{}, AllocationRequest{Free: []int{2, 3, 5}}, 2, true},
+		{"lowest number without free spots", LowestNumberAllocator{}, AllocationRequest{}, 0, false},
+		{"first free preferred spot", PreferredSpotsAllocator{}, AllocationRequest{Free: []int{2, 3, 5}, Preferred: []int{4, 5, 3}}, 5, true},
+		{"preferred spots taken", PreferredSpotsAllocator{Fallback: SpreadAllocator{}}, AllocationRequest{Free: []int{2, 3, 5}, Taken: []int{1}, Preferred: []int{1}}, 5, true},
+		{"spread away from taken spots", SpreadAllocator{}, AllocationRequest{Free: []int{2, 3, 4, 6, 7}, Taken: []int{1, 5, 8}}, 3, true},
+		{"spread with nothing taken", SpreadAllocator{}, AllocationRequest{Free: []int{2, 3}}, 2, true},
+		{"spread without free spots", SpreadAllocator{}, AllocationRequest{Taken: []int{1}}, 0, false},
+		{"best fit keeps equipped spots", BestFitAllocator{}, AllocationRequest{Free: []int{1, 2, 3}, Location: location, Requirements: model.TruckRequirements{LengthM: 6, WidthM: 2.5}}, 2, true},
+		{"best fit for a truck needing water", BestFitAllocator{}, AllocationRequest{Free: []int{1, 3}, Location: location, Requirements: model.TruckRequirements{LengthM: 6, Water: true}}, 3, true},
+		{"best fit ties go to the lowest number", BestFitAllocator{}, AllocationRequest{Free: []int{2, 6}, Location: location}, 2, true},
+		{"best fit without free spots", BestFitAllocator{}, AllocationRequest{Location: location}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.allocator.Allocate(&tt.request)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("Allocate() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewSpotAllocator(t *testing.T) {
+	for _, strategy := range []string{"", AllocateLowest, AllocatePreferred, AllocateSpread, AllocateBestFit} {
+		if _, err := NewSpotAllocator(strategy); err != nil {
+			t.Fatalf("expected strategy %q to exist: %v", strategy, err)
+		}
+	}
+	if _, err := NewSpotAllocator("random"); err == nil {
+		t.Fatal("expected an error for an unknown strategy")
+	}
+}
+
+func TestValidatePreferredSpots(t *testing.T) {
+	if err := validatePreferredSpots([]int{3, 1}); err != nil {
+		t.Fatalf("expected preferred spots to be valid: %v", err)
+	}
+	if err := validatePreferredSpots([]int{0}); err == nil {
+		t.Fatal("expected an error for a non positive spot number")
+	}
+	if err := validatePreferredSpots([]int{2, 2}); err == nil {
+		t.Fatal("expected an error for a duplicate spot number")
+	}
+}
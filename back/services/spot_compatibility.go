@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// IncompatibleSpotError is returned when a spot number cannot host a food truck
+type IncompatibleSpotError struct {
+	SpotNumber int
+	Reasons    []string
+}
+
+func (e *IncompatibleSpotError) Error() string {
+	return fmt.Sprintf("spot %d does not suit this food truck: %s", e.SpotNumber, strings.Join(e.Reasons, ", "))
+}
+
+// spotIncompatibilities lists why a spot cannot host a truck, empty when the truck fits
+func spotIncompatibilities(spot model.SpotAttributes, requirements model.TruckRequirements) []string {
+	reasons := []string{}
+	if spot.LengthM > 0 && requirements.LengthM > spot.LengthM {
+		reasons = append(reasons, fmt.Sprintf("truck is %.1fm long but the spot is %.1fm", requirements.LengthM, spot.LengthM))
+	}
+	if spot.WidthM > 0 && requirements.WidthM > spot.WidthM {
+		reasons = append(reasons, fmt.Sprintf("truck is %.1fm wide but the spot is %.1fm", requirements.WidthM, spot.WidthM))
+	}
+	if requirements.ElectricityAmps > spot.ElectricityAmps {
+		if spot.ElectricityAmps == 0 {
+			reasons = append(reasons, "no power hookup")
+		} else {
+			reasons = append(reasons, fmt.Sprintf("truck needs %dA but the hookup provides %dA", requirements.ElectricityAmps, spot.ElectricityAmps))
+		}
+	}
+	if requirements.Water && !spot.Water {
+		reasons = append(reasons, "no water supply")
+	}
+	if requirements.Covered && !spot.Covered {
+		reasons = append(reasons, "spot is not covered")
+	}
+	return reasons
+}
+
+// spotAttributes returns the attributes of a spot number of a location, none when they were not described
+func spotAttributes(location *model.Location, spotNumber int) model.SpotAttributes {
+	for _, spot := range location.Spots {
+		if spot.Number == spotNumber {
+			return spot
+		}
+	}
+	return model.SpotAttributes{Number: spotNumber}
+}
+
+// checkSpotCompatibility returns an IncompatibleSpotError when the spot number cannot host the truck
+func checkSpotCompatibility(location *model.Location, spotNumber int, requirements model.TruckRequirements) error {
+	if reasons := spotIncompatibilities(spotAttributes(location, spotNumber), requirements); len(reasons) > 0 {
+		return &IncompatibleSpotError{SpotNumber: spotNumber, Reasons: reasons}
+	}
+	return nil
+}
+
+// compatibleSpotNumbers keeps the spot numbers able to host a truck
+func compatibleSpotNumbers(location *model.Location, spotNumbers []int, requirements model.TruckRequirements) []int {
+	compatible := []int{}
+	for _, num := range spotNumbers {
+		if checkSpotCompatibility(location, num, requirements) == nil {
+			compatible = append(compatible, num)
+		}
+	}
+	return compatible
+}
+
+// validateSpotAttributes checks that spot numbers are positive and unique and measurements are not negative
+func validateSpotAttributes(spots []model.SpotAttributes) error {
+	seen := make(map[int]bool, len(spots))
+	for _, spot := range spots {
+		if spot.Number <= 0 {
+			return fmt.Errorf("invalid spot number %d", spot.Number)
+		}
+		if seen[spot.Number] {
+			return fmt.Errorf("duplicate spot number %d", spot.Number)
+		}
+		seen[spot.Number] = true
+
+		if spot.LengthM < 0 || spot.WidthM < 0 || spot.ElectricityAmps < 0 {
+			return fmt.Errorf("spot %d: measurements cannot be negative", spot.Number)
+		}
+	}
+	return nil
+}
+
+// validateRequirements checks that the requirements of a truck are not negative
+func validateRequirements(requirements model.TruckRequirements) error {
+	if requirements.LengthM < 0 || requirements.WidthM < 0 || requirements.ElectricityAmps < 0 {
+		return errors.New("requirements cannot be negative")
+	}
+	return nil
+}
+
+// truckRequirements returns the requirements of a food truck. Unknown trucks have no requirements.
+func truckRequirements(ctx context.Context, foodtrucks *mongo.Collection, foodTruckID primitive.ObjectID) (model.TruckRequirements, error) {
+	foodtruck, err := findFoodtruck(ctx, foodtrucks, foodTruckID)
+	if err != nil {
+		return model.TruckRequirements{}, err
+	}
+	return foodtruck.Requirements, nil
+}
+
+// findFoodtruck returns a food truck, an empty one without requirements or preferences when it is unknown
+func findFoodtruck(ctx context.Context, foodtrucks *mongo.Collection, foodTruckID primitive.ObjectID) (*model.Foodtruck, error) {
+	var foodtruck model.Foodtruck
+	err := foodtrucks.FindOne(ctx, bson.M{"_id": foodTruckID}).Decode(&foodtruck)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to fetch food truck: %v", err)
+	}
+	return &foodtruck, nil
+}
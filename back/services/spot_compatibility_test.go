@@ -0,0 +1,66 @@
+package services
+
+import (
+	"gitlab.com/hooly2/back/model"
+	"reflect"
+	"testing"
+)
+
+func TestSpotIncompatibilities(t *testing.T) {
+	spot := model.SpotAttributes{Number: 3, LengthM: 6, WidthM: 2.5, ElectricityAmps: 16, Water: true}
+
+	tests := []struct {
+		name         string
+		spot         model.SpotAttributes
+		requirements model.TruckRequirements
+		wantReasons  int
+	}{
+		{"no requirements", spot, model.TruckRequirements{}, 0},
+		{"fits exactly", spot, model.TruckRequirements{LengthM: 6, WidthM: 2.5, ElectricityAmps: 16, Water: true}, 0},
+		{"trailer too long", spot, model.TruckRequirements{LengthM: 8.5}, 1},
+		{"too wide and needs more power", spot, model.TruckRequirements{WidthM: 3, ElectricityAmps: 32}, 2},
+		{"needs shelter", spot, model.TruckRequirements{Covered: true}, 1},
+		{"unlimited dimensions", model.SpotAttributes{Number: 1}, model.TruckRequirements{LengthM: 12, WidthM: 3}, 0},
+		{"no hookup", model.SpotAttributes{Number: 1}, model.TruckRequirements{ElectricityAmps: 16, Water: true}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons := spotIncompatibilities(tt.spot, tt.requirements)
+			if len(reasons) != tt.wantReasons {
+				t.Fatalf("expected %d reasons, got %v", tt.wantReasons, reasons)
+			}
+		})
+	}
+}
+
+func TestCompatibleSpotNumbers(t *testing.T) {
+	location := &model.Location{Spots: []model.SpotAttributes{
+		{Number: 1, LengthM: 5},
+		{Number: 2, LengthM: 8, ElectricityAmps: 32},
+		{Number: 3, LengthM: 8},
+	}}
+
+	compatible := compatibleSpotNumbers(location, []int{1, 2, 3, 4}, model.TruckRequirements{LengthM: 7, ElectricityAmps: 16})
+	if !reflect.DeepEqual(compatible, []int{2}) {
+		t.Fatalf("expected only spot 2 to fit, got %v", compatible)
+	}
+
+	err := checkSpotCompatibility(location, 1, model.TruckRequirements{LengthM: 7})
+	incompatibleErr, ok := err.(*IncompatibleSpotError)
+	if !ok || incompatibleErr.SpotNumber != 1 || len(incompatibleErr.Reasons) != 1 {
+		t.Fatalf("expected an IncompatibleSpotError for spot 1, got %v", err)
+	}
+}
+
+func TestValidateSpotAttributes(t *testing.T) {
+	if err := validateSpotAttributes([]model.SpotAttributes{{Number: 1}, {Number: 2, LengthM: 6}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateSpotAttributes([]model.SpotAttributes{{Number: 1}, {Number: 1}}); err == nil {
+		t.Fatal("expected an error for a duplicate spot number")
+	}
+	if err := validateSpotAttributes([]model.SpotAttributes{{Number: 1, WidthM: -1}}); err == nil {
+		t.Fatal("expected an error for a negative width")
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LayoutConflictError is returned when a layout change would strand future reservations
+type LayoutConflictError struct {
+	Reasons      []string
+	Reservations []model.Reservation
+}
+
+func (e *LayoutConflictError) Error() string {
+	return "layout change conflicts with future reservations: " + strings.Join(e.Reasons, "; ")
+}
+
+// defaultSpotNumbers returns the layout used when a weekday is created without one: 6 spots on Friday, 7 otherwise
+func defaultSpotNumbers(dayOfWeek string) []int {
+	count := 7
+	if dayOfWeek == "Friday" {
+		count = 6
+	}
+
+	spotNumbers := make([]int, count)
+	for i := range spotNumbers {
+		spotNumbers[i] = i + 1
+	}
+	return spotNumbers
+}
+
+// validateLayout checks that spot numbers are positive and unique and that the capacity fits the layout.
+// A zero capacity defaults to one truck per spot number.
+func validateLayout(spotNumbers []int, maxCapacity int) (int, error) {
+	if len(spotNumbers) == 0 {
+		return 0, errors.New("a layout needs at least one spot number")
+	}
+
+	seen := make(map[int]bool, len(spotNumbers))
+	for _, num := range spotNumbers {
+		if num <= 0 {
+			return 0, fmt.Errorf("invalid spot number %d", num)
+		}
+		if seen[num] {
+			return 0, fmt.Errorf("duplicate spot number %d", num)
+		}
+		seen[num] = true
+	}
+
+	if maxCapacity == 0 {
+		maxCapacity = len(spotNumbers)
+	}
+	if maxCapacity < 0 || maxCapacity > len(spotNumbers) {
+		return 0, fmt.Errorf("max_capacity must be between 1 and %d", len(spotNumbers))
+	}
+
+	return maxCapacity, nil
+}
+
+// removedSpotNumbers lists the spot numbers of the old layout missing from the new one
+func removedSpotNumbers(oldNumbers, newNumbers []int) []int {
+	removed := []int{}
+	for _, num := range oldNumbers {
+		if !containsInt(newNumbers, num) {
+			removed = append(removed, num)
+		}
+	}
+	return removed
+}
+
+// UpdateParkingSpotLayout replaces the spot numbers, capacity and slots of a weekday. Future reservations on removed spot
+// numbers must be relocated, relocations maps each removed spot number to its replacement in the new layout, which must
+// suit their food truck. Slots with future reservations and spot numbers with live holds cannot be removed. Nothing is
+// changed when a relocation fails. It returns the updated parking spot and the relocated reservations.
+func (s *ParkingSpotService) UpdateParkingSpotLayout(ctx context.Context, spotID primitive.ObjectID, spotNumbers []int, maxCapacity int, slots []model.TimeSlot, relocations map[int]int) (*model.ParkingSpot, []model.Reservation, error) {
+	maxCapacity, err := validateLayout(spotNumbers, maxCapacity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateSlots(slots, len(spotNumbers)); err != nil {
+		return nil, nil, err
+	}
+	sort.Ints(spotNumbers)
+
+	var parkingSpot model.ParkingSpot
+	if err := s.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": spotID}).Decode(&parkingSpot); err != nil {
+		return nil, nil, errors.New("parking spot not found")
+	}
+
+	for from, to := range relocations {
+		if containsInt(spotNumbers, from) {
+			return nil, nil, fmt.Errorf("spot number %d is kept in the new layout and cannot be relocated", from)
+		}
+		if !containsInt(spotNumbers, to) {
+			return nil, nil, fmt.Errorf("relocation target %d is not in the new layout", to)
+		}
+	}
+
+	location, err := s.Locations.GetLocation(ctx, parkingSpot.LocationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	today := locationToday(location, time.Now())
+	conflict := &LayoutConflictError{}
+
+	updated := parkingSpot
+	updated.SpotNumbers = spotNumbers
+	updated.MaxCapacity = maxCapacity
+	updated.Slots = slots
+
+	// Slots cannot be removed while future reservations use them
+	keptSlots := []string{}
+	for _, slot := range spotSlots(&updated) {
+		keptSlots = append(keptSlots, slotKey(slot))
+	}
+	removedSlots := []string{}
+	for _, slot := range spotSlots(&parkingSpot) {
+		if !containsString(keptSlots, slotKey(slot)) {
+			removedSlots = append(removedSlots, slotKey(slot))
+		}
+	}
+	if len(removedSlots) > 0 {
+		var booked []model.Reservation
+		cursor, err := s.ReservationCollection.Find(ctx, bson.M{
+			"spot_id": spotID,
+			"slot_id": slotFilter(removedSlots...),
+			"date":    bson.M{"$gte": today},
+			"status":  activeStatusFilter(),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch reservations: %v", err)
+		}
+		if err := cursor.All(ctx, &booked); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode reservations: %v", err)
+		}
+		for _, reservation := range booked {
+			slot := reservation.SlotID
+			if slot == "" {
+				slot = "whole day"
+			}
+			conflict.Reasons = append(conflict.Reasons, fmt.Sprintf("slot %s is reserved on %s", slot, reservation.Date.Format(utils.DateLayout)))
+			conflict.Reservations = append(conflict.Reservations, reservation)
+		}
+	}
+
+	// The capacity of each slot cannot drop below what is already booked on a future date
+	overbookedFilter := bson.A{}
+	for _, slot := range spotSlots(&updated) {
+		overbookedFilter = append(overbookedFilter, bson.M{
+			"slot_id":        slotFilter(slotKey(slot)),
+			"reserved_count": bson.M{"$gt": slotCapacity(&updated, slot)},
+		})
+	}
+	cursor, err := s.Occupancy.OccupancyCollection.Find(ctx, bson.M{
+		"spot_id": spotID,
+		"date":    bson.M{"$gte": today},
+		"$or":     overbookedFilter,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch spot occupancy: %v", err)
+	}
+	var overbooked []model.SpotOccupancy
+	if err := cursor.All(ctx, &overbooked); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode spot occupancy: %v", err)
+	}
+	for _, occupancy := range overbooked {
+		conflict.Reasons = append(conflict.Reasons, fmt.Sprintf("%d trucks booked on %s", occupancy.ReservedCount, occupancy.Date.Format(utils.DateLayout)))
+	}
+
+	// Every future reservation on a removed spot number needs a free replacement, and no live hold may use one
+	var stranded []model.Reservation
+	if removed := removedSpotNumbers(parkingSpot.SpotNumbers, spotNumbers); len(removed) > 0 {
+		cursor, err := s.ReservationCollection.Find(ctx, bson.M{
+			"spot_id":     spotID,
+			"spot_number": bson.M{"$in": removed},
+			"date":        bson.M{"$gte": today},
+			"status":      activeStatusFilter(),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch reservations: %v", err)
+		}
+		if err := cursor.All(ctx, &stranded); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode reservations: %v", err)
+		}
+
+		var holds []model.ReservationHold
+		cursor, err = s.HoldCollection.Find(ctx, bson.M{
+			"spot_id":     spotID,
+			"spot_number": bson.M{"$in": removed},
+			"date":        bson.M{"$gte": today},
+			"expires_at":  bson.M{"$gt": time.Now()},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch holds: %v", err)
+		}
+		if err := cursor.All(ctx, &holds); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode holds: %v", err)
+		}
+		for _, hold := range holds {
+			conflict.Reasons = append(conflict.Reasons, fmt.Sprintf("spot %d is held on %s until %s",
+				hold.SpotNumber, hold.Date.Format(utils.DateLayout), hold.ExpiresAt.Format(time.RFC3339)))
+		}
+	}
+
+	type slotDate struct {
+		date   time.Time
+		slotID string
+	}
+	targetsByDate := make(map[slotDate][]int)
+	for _, reservation := range stranded {
+		date := reservation.Date.Format(utils.DateLayout)
+		to, ok := relocations[reservation.SpotNumber]
+		if !ok {
+			conflict.Reasons = append(conflict.Reasons, fmt.Sprintf("spot %d is reserved on %s", reservation.SpotNumber, date))
+			conflict.Reservations = append(conflict.Reservations, reservation)
+			continue
+		}
+
+		requirements, err := truckRequirements(ctx, s.FoodtruckCollection, reservation.FoodTruckID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkSpotCompatibility(location, to, requirements); err != nil {
+			conflict.Reasons = append(conflict.Reasons, fmt.Sprintf("%s on %s", err.Error(), date))
+			conflict.Reservations = append(conflict.Reservations, reservation)
+			continue
+		}
+
+		occupancy, err := s.Occupancy.GetOccupancy(ctx, spotID, reservation.Date, reservation.SlotID)
+		if err != nil {
+			return nil, nil, err
+		}
+		day := slotDate{utils.TruncateToDay(reservation.Date), reservation.SlotID}
+		if containsInt(occupancy.TakenSpots, to) || containsInt(targetsByDate[day], to) {
+			conflict.Reasons = append(conflict.Reasons, fmt.Sprintf("spot %d is already taken on %s", to, date))
+			conflict.Reservations = append(conflict.Reservations, reservation)
+			continue
+		}
+		targetsByDate[day] = append(targetsByDate[day], to)
+	}
+
+	if len(conflict.Reasons) > 0 {
+		return nil, nil, conflict
+	}
+
+	// Move the stranded reservations, then store the new layout. A failure moves them back to their spot numbers.
+	moved := []model.Reservation{}
+	undo := func() {
+		for i := len(moved) - 1; i >= 0; i-- {
+			reservation := moved[i]
+			to := relocations[reservation.SpotNumber]
+			if err := s.Occupancy.Move(ctx, spotID, reservation.Date, reservation.SlotID, to, reservation.SpotNumber); err != nil {
+				log.Println("Error undoing relocation of reservation", reservation.ID.Hex(), err)
+			}
+			_, err := s.ReservationCollection.UpdateOne(ctx, bson.M{"_id": reservation.ID}, bson.M{"$set": bson.M{"spot_number": reservation.SpotNumber}})
+			if err != nil {
+				log.Println("Error undoing relocation of reservation", reservation.ID.Hex(), err)
+			}
+		}
+	}
+
+	for _, reservation := range stranded {
+		to := relocations[reservation.SpotNumber]
+		if err := s.Occupancy.Move(ctx, spotID, reservation.Date, reservation.SlotID, reservation.SpotNumber, to); err != nil {
+			undo()
+			return nil, nil, fmt.Errorf("failed to relocate reservation %s: %w", reservation.ID.Hex(), err)
+		}
+		moved = append(moved, reservation)
+		_, err := s.ReservationCollection.UpdateOne(ctx, bson.M{"_id": reservation.ID}, bson.M{"$set": bson.M{"spot_number": to}})
+		if err != nil {
+			undo()
+			return nil, nil, fmt.Errorf("failed to relocate reservation %s: %v", reservation.ID.Hex(), err)
+		}
+	}
+
+	update := bson.M{"$set": bson.M{"spot_numbers": spotNumbers, "max_capacity": maxCapacity, "slots": slots}}
+	if _, err := s.ParkingSpotCollection.UpdateOne(ctx, bson.M{"_id": spotID}, update); err != nil {
+		undo()
+		return nil, nil, fmt.Errorf("failed to update parking spot layout: %v", err)
+	}
+
+	// Tell the owners once the new layout is stored
+	relocated := []model.Reservation{}
+	for _, reservation := range moved {
+		to := relocations[reservation.SpotNumber]
+		notification := model.Notification{
+			UserID:        reservation.UserID,
+			Type:          model.NotificationReservationRelocated,
+			ReservationID: reservation.ID,
+			Message: fmt.Sprintf("Your reservation on %s was moved from spot %d to spot %d",
+				reservation.Date.Format(utils.DateLayout), reservation.SpotNumber, to),
+		}
+		if err := s.Notifications.Notify(ctx, &notification); err != nil {
+			log.Println("Error notifying relocation:", err)
+		}
+
+		reservation.SpotNumber = to
+		relocated = append(relocated, reservation)
+	}
+
+	return &updated, relocated, nil
+}
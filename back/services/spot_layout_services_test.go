@@ -0,0 +1,52 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultSpotNumbers(t *testing.T) {
+	if got := defaultSpotNumbers("Friday"); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6}) {
+		t.Fatalf("expected 6 spots on Friday, got %v", got)
+	}
+	if got := defaultSpotNumbers("Monday"); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6, 7}) {
+		t.Fatalf("expected 7 spots on Monday, got %v", got)
+	}
+}
+
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		name         string
+		spotNumbers  []int
+		maxCapacity  int
+		wantCapacity int
+		wantErr      bool
+	}{
+		{"capacity defaults to spot count", []int{1, 2, 3}, 0, 3, false},
+		{"smaller capacity", []int{1, 2, 3, 8}, 2, 2, false},
+		{"empty layout", []int{}, 0, 0, true},
+		{"duplicate spot number", []int{1, 2, 2}, 0, 0, true},
+		{"non positive spot number", []int{0, 1}, 0, 0, true},
+		{"capacity above spot count", []int{1, 2}, 3, 0, true},
+		{"negative capacity", []int{1, 2}, -1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity, err := validateLayout(tt.spotNumbers, tt.maxCapacity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if capacity != tt.wantCapacity {
+				t.Fatalf("validateLayout() capacity = %d, expected %d", capacity, tt.wantCapacity)
+			}
+		})
+	}
+}
+
+func TestRemovedSpotNumbers(t *testing.T) {
+	removed := removedSpotNumbers([]int{1, 2, 3, 4, 5, 6, 7}, []int{1, 2, 4, 5, 8})
+	if !reflect.DeepEqual(removed, []int{3, 6, 7}) {
+		t.Fatalf("expected [3 6 7] removed, got %v", removed)
+	}
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// ErrReservationChanged is returned when a reservation changed between being checked and being given to another
+// food truck
+var ErrReservationChanged = errors.New("reservation changed meanwhile, try again")
+
+// SwapService lets food trucks propose to exchange reservations, the other food truck accepting or declining
+type SwapService struct {
+	SwapCollection *mongo.Collection
+	Reservations   *ReservationService
+}
+
+func NewSwapService(reservationService *ReservationService) *SwapService {
+	return &SwapService{
+		SwapCollection: db.GetCollection("reservationSwap"),
+		Reservations:   reservationService,
+	}
+}
+
+// ProposeSwap offers a reservation of a food truck the user books for in exchange for a reservation of another
+// food truck, whose owner is notified. The exchange is checked right away so that hopeless proposals are refused.
+func (s *SwapService) ProposeSwap(ctx context.Context, swap *model.ReservationSwap) error {
+	if swap.ReservationID == swap.TargetReservationID {
+		return errors.New("cannot swap a reservation with itself")
+	}
+
+	offered, err := s.Reservations.GetReservationByID(ctx, swap.ReservationID, primitive.NilObjectID)
+	if err != nil {
+		return errors.New("reservation not found")
+	}
+	wanted, err := s.Reservations.GetReservationByID(ctx, swap.TargetReservationID, primitive.NilObjectID)
+	if err != nil {
+		return errors.New("target reservation not found")
+	}
+
+	// Only trucks the user may book for can offer their reservations
+	if _, err := s.Reservations.bookableFoodtruck(ctx, offered.FoodTruckID, swap.ProposerID); err != nil {
+		return err
+	}
+	_, target, err := s.Reservations.checkSwap(ctx, offered, wanted)
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.SwapCollection.CountDocuments(ctx, bson.M{
+		"reservation_id":        offered.ID,
+		"target_reservation_id": wanted.ID,
+		"status":                model.SwapProposed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check existing swaps: %v", err)
+	}
+	if pending > 0 {
+		return errors.New("this swap was already proposed")
+	}
+
+	swap.ID = primitive.NewObjectID()
+	swap.FoodTruckID = offered.FoodTruckID
+	swap.TargetFoodTruckID = wanted.FoodTruckID
+	swap.TargetUserID = target.UserID
+	swap.Status = model.SwapProposed
+	swap.CreatedAt = time.Now()
+	swap.RespondedAt = nil
+	swap.RespondedBy = primitive.NilObjectID
+	if _, err := s.SwapCollection.InsertOne(ctx, swap); err != nil {
+		return fmt.Errorf("failed to propose swap: %v", err)
+	}
+
+	s.notify(ctx, target.UserID, model.NotificationSwapProposed, wanted.ID, fmt.Sprintf(
+		"%s proposes its reservation of %s in exchange for yours of %s",
+		offered.FoodTruckName, offered.Date.Format(utils.DateLayout), wanted.Date.Format(utils.DateLayout)))
+
+	return nil
+}
+
+// GetUserSwaps lists the swaps proposed by or to the user, newest first
+func (s *SwapService) GetUserSwaps(ctx context.Context, userID primitive.ObjectID) ([]model.ReservationSwap, error) {
+	filter := bson.M{"$or": []bson.M{{"proposer_id": userID}, {"target_user_id": userID}}}
+	cursor, err := s.SwapCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	swaps := []model.ReservationSwap{}
+	if err := cursor.All(ctx, &swaps); err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+// RespondToSwap accepts or declines a swap proposed to a food truck the user books for. Accepting exchanges the
+// reservations; a swap whose reservations changed meanwhile fails, and one refused by the quotas stays proposed so
+// that it can be accepted later.
+func (s *SwapService) RespondToSwap(ctx context.Context, swapID primitive.ObjectID, userID primitive.ObjectID, accept bool) (*model.ReservationSwap, error) {
+	var swap model.ReservationSwap
+	if err := s.SwapCollection.FindOne(ctx, bson.M{"_id": swapID}).Decode(&swap); err != nil {
+		return nil, errors.New("swap not found")
+	}
+
+	target, err := findFoodtruck(ctx, s.Reservations.FoodtruckCollection, swap.TargetFoodTruckID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID.IsZero() || !canBookFor(target, userID, s.Reservations.userRole(ctx, userID)) {
+		return nil, errors.New("swap not found")
+	}
+
+	if !accept {
+		if err := s.closeSwap(ctx, &swap, model.SwapProposed, model.SwapDeclined, userID); err != nil {
+			return nil, err
+		}
+		s.notify(ctx, swap.ProposerID, model.NotificationSwapDeclined, swap.ReservationID, "Your swap proposal was declined")
+		return &swap, nil
+	}
+
+	// Claim the swap so that it is only accepted once
+	result, err := s.SwapCollection.UpdateOne(ctx, bson.M{"_id": swap.ID, "status": model.SwapProposed}, bson.M{"$set": bson.M{"status": model.SwapAccepting}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept swap: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("swap was already %s", swap.Status)
+	}
+
+	if err := s.exchange(ctx, &swap); err != nil {
+		// The quotas may allow the exchange later, unlike a reservation that is gone
+		if errors.Is(err, ErrReservationChanged) {
+			if closeErr := s.closeSwap(ctx, &swap, model.SwapAccepting, model.SwapFailed, userID); closeErr != nil {
+				log.Println("Error closing swap:", closeErr)
+			}
+		} else if _, reopenErr := s.SwapCollection.UpdateOne(ctx, bson.M{"_id": swap.ID, "status": model.SwapAccepting}, bson.M{"$set": bson.M{"status": model.SwapProposed}}); reopenErr != nil {
+			log.Println("Error reopening swap:", reopenErr)
+		}
+		return nil, err
+	}
+
+	if err := s.closeSwap(ctx, &swap, model.SwapAccepting, model.SwapAccepted, userID); err != nil {
+		return nil, err
+	}
+	s.notify(ctx, swap.ProposerID, model.NotificationSwapAccepted, swap.ReservationID, "Your swap proposal was accepted, the reservations were exchanged")
+
+	return &swap, nil
+}
+
+// CancelSwap withdraws a swap the user proposed while it has not been answered
+func (s *SwapService) CancelSwap(ctx context.Context, swapID primitive.ObjectID, userID primitive.ObjectID) error {
+	result, err := s.SwapCollection.UpdateOne(ctx,
+		bson.M{"_id": swapID, "proposer_id": userID, "status": model.SwapProposed},
+		bson.M{"$set": bson.M{"status": model.SwapCancelled}})
+	if err != nil {
+		return fmt.Errorf("failed to cancel swap: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("swap not found or already answered")
+	}
+	return nil
+}
+
+// exchange swaps the reservations of a swap, provided they still belong to the food trucks it was proposed for
+func (s *SwapService) exchange(ctx context.Context, swap *model.ReservationSwap) error {
+	offered, err := s.Reservations.GetReservationByID(ctx, swap.ReservationID, primitive.NilObjectID)
+	if err != nil {
+		return ErrReservationChanged
+	}
+	wanted, err := s.Reservations.GetReservationByID(ctx, swap.TargetReservationID, primitive.NilObjectID)
+	if err != nil {
+		return ErrReservationChanged
+	}
+	if offered.FoodTruckID != swap.FoodTruckID || wanted.FoodTruckID != swap.TargetFoodTruckID {
+		return ErrReservationChanged
+	}
+	for _, reservation := range []*model.Reservation{offered, wanted} {
+		today, err := s.Reservations.todayAt(ctx, reservation.LocationID, time.Now())
+		if err != nil {
+			return err
+		}
+		if exchangeable(reservation, today) != nil {
+			return ErrReservationChanged
+		}
+	}
+
+	return s.Reservations.SwapReservations(ctx, offered, wanted)
+}
+
+// closeSwap moves a swap from one status to another, recording who answered it
+func (s *SwapService) closeSwap(ctx context.Context, swap *model.ReservationSwap, from, to string, userID primitive.ObjectID) error {
+	now := time.Now()
+	result, err := s.SwapCollection.UpdateOne(ctx, bson.M{"_id": swap.ID, "status": from}, bson.M{"$set": bson.M{
+		"status":       to,
+		"responded_at": now,
+		"responded_by": userID,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to update swap: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("swap is no longer %s", from)
+	}
+
+	swap.Status = to
+	swap.RespondedAt = &now
+	swap.RespondedBy = userID
+	return nil
+}
+
+// notify tells a user about a swap, a failure only being logged
+func (s *SwapService) notify(ctx context.Context, userID primitive.ObjectID, kind string, reservationID primitive.ObjectID, message string) {
+	notification := model.Notification{UserID: userID, Type: kind, ReservationID: reservationID, Message: message}
+	if err := s.Reservations.Notifications.Notify(ctx, &notification); err != nil {
+		log.Println("Error notifying swap:", err)
+	}
+}
+
+// SwapReservations exchanges the food trucks of two reservations: each takes the date, slot and spot number of the
+// other, so the occupancy of the spots is unchanged. Both trucks must stay within their quotas, the reservation they
+// give up being left out. Both reservations are reassigned in a single transaction, neither changing if the other
+// changed meanwhile, which needs MongoDB to run as a replica set.
+func (s *ReservationService) SwapReservations(ctx context.Context, first, second *model.Reservation) error {
+	firstTruck, secondTruck, err := s.checkSwap(ctx, first, second)
+	if err != nil {
+		return err
+	}
+
+	session, err := s.ReservationCollection.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start swap: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		if err := s.assignFoodtruck(sessionCtx, first, secondTruck, second.UserID); err != nil {
+			return nil, err
+		}
+		return nil, s.assignFoodtruck(sessionCtx, second, firstTruck, first.UserID)
+	})
+	return err
+}
+
+// TransferReservation gives a reservation to another food truck of the same owner, the user having to book for
+// both. The new food truck must stay within its quotas and fit the spot number.
+func (s *ReservationService) TransferReservation(ctx context.Context, reservationID, foodTruckID, userID primitive.ObjectID) (*model.Reservation, error) {
+	reservation, err := s.GetReservationByID(ctx, reservationID, primitive.NilObjectID)
+	if err != nil {
+		return nil, errors.New("reservation not found")
+	}
+
+	current, err := findFoodtruck(ctx, s.FoodtruckCollection, reservation.FoodTruckID)
+	if err != nil {
+		return nil, err
+	}
+	if !canBookFor(current, userID, s.userRole(ctx, userID)) {
+		return nil, errors.New("reservation not found")
+	}
+	today, err := s.todayAt(ctx, reservation.LocationID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := exchangeable(reservation, today); err != nil {
+		return nil, err
+	}
+	if reservation.FoodTruckID == foodTruckID {
+		return nil, errors.New("reservation already belongs to this food truck")
+	}
+
+	target, err := s.bookableFoodtruck(ctx, foodTruckID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target.UserID != current.UserID {
+		return nil, errors.New("reservations can only be transferred between food trucks of the same owner")
+	}
+	if err := s.checkExchange(ctx, reservation, target, reservation.UserID, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
+	if err := s.assignFoodtruck(ctx, reservation, target, reservation.UserID); err != nil {
+		return nil, err
+	}
+	reservation.FoodTruckID = target.ID
+	reservation.FoodTruckName = target.Name
+
+	return reservation, nil
+}
+
+// checkSwap checks that two reservations can be exchanged and returns their food trucks
+func (s *ReservationService) checkSwap(ctx context.Context, first, second *model.Reservation) (*model.Foodtruck, *model.Foodtruck, error) {
+	if first.FoodTruckID == second.FoodTruckID {
+		return nil, nil, errors.New("cannot swap reservations of the same food truck")
+	}
+	for _, reservation := range []*model.Reservation{first, second} {
+		today, err := s.todayAt(ctx, reservation.LocationID, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := exchangeable(reservation, today); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	firstTruck, err := findFoodtruck(ctx, s.FoodtruckCollection, first.FoodTruckID)
+	if err != nil {
+		return nil, nil, err
+	}
+	secondTruck, err := findFoodtruck(ctx, s.FoodtruckCollection, second.FoodTruckID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if firstTruck.ID.IsZero() || secondTruck.ID.IsZero() {
+		return nil, nil, ErrFoodtruckNotFound
+	}
+
+	if err := s.checkExchange(ctx, second, firstTruck, first.UserID, first.ID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkExchange(ctx, first, secondTruck, second.UserID, second.ID); err != nil {
+		return nil, nil, err
+	}
+
+	return firstTruck, secondTruck, nil
+}
+
+// checkExchange checks that a food truck can take over a reservation booked by userID: the user must not be banned
+// from booking, and the food truck must be in good standing, fit the spot number and stay within its quotas, leaving
+// out the reservation it gives up in exchange if any
+func (s *ReservationService) checkExchange(ctx context.Context, reservation *model.Reservation, foodtruck *model.Foodtruck, userID, replaces primitive.ObjectID) error {
+	if err := s.checkBookingBan(ctx, userID); err != nil {
+		return err
+	}
+	if err := checkFoodtruckStanding(foodtruck, time.Now()); err != nil {
+		return err
+	}
+
+	candidate := *reservation
+	candidate.FoodTruckID = foodtruck.ID
+	candidate.UserID = userID
+	request := &BookingRequest{Reservation: &candidate, Role: s.userRole(ctx, userID), Now: time.Now()}
+	if !replaces.IsZero() {
+		request.Replaces = []primitive.ObjectID{replaces}
+	}
+
+	policy, err := s.Settings.GetBookingPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if err := NewBookingPolicyChain(policy, s.ReservationCollection).QuotaRules().Check(ctx, request); err != nil {
+		return err
+	}
+
+	location, err := s.Locations.GetLocation(ctx, reservation.LocationID)
+	if err != nil {
+		return err
+	}
+	requirements, err := truckRequirements(ctx, s.FoodtruckCollection, foodtruck.ID)
+	if err != nil {
+		return err
+	}
+	return checkSpotCompatibility(location, reservation.SpotNumber, requirements)
+}
+
+// assignFoodtruck gives a reservation to a food truck, booked by userID, unless it changed since it was read
+func (s *ReservationService) assignFoodtruck(ctx context.Context, reservation *model.Reservation, foodtruck *model.Foodtruck, userID primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":           reservation.ID,
+		"food_truck_id": reservation.FoodTruckID,
+		"user_id":       reservation.UserID,
+		"date":          reservation.Date,
+		"spot_number":   reservation.SpotNumber,
+		"status":        activeStatusFilter(),
+	}
+	result, err := s.ReservationCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"food_truck_id":   foodtruck.ID,
+		"food_truck_name": foodtruck.Name,
+		"user_id":         userID,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to update reservation: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrReservationChanged
+	}
+	return nil
+}
+
+// exchangeable refuses reservations that are over, given the day it is at their location, or no longer hold their
+// spot number
+func exchangeable(reservation *model.Reservation, today time.Time) error {
+	status := reservationStatus(reservation)
+	if status != model.StatusConfirmed && status != model.StatusPending {
+		return fmt.Errorf("cannot exchange a reservation that is %s", status)
+	}
+	if reservation.Date.Before(today) {
+		return errors.New("cannot exchange a past reservation")
+	}
+	return nil
+}
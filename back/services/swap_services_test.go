@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+// newTestSwapService wires a SwapService on the database of a reservation service
+func newTestSwapService(service *ReservationService) *SwapService {
+	return &SwapService{SwapCollection: service.ReservationCollection.Database().Collection("reservationSwap"), Reservations: service}
+}
+
+func TestExchangeable(t *testing.T) {
+	today := time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		status  string
+		date    time.Time
+		wantErr bool
+	}{
+		{"confirmed", model.StatusConfirmed, today.AddDate(0, 0, 1), false},
+		{"pending", model.StatusPending, today.AddDate(0, 0, 1), false},
+		{"legacy without status", "", today.AddDate(0, 0, 1), false},
+		{"today", model.StatusConfirmed, today, false},
+		{"past", model.StatusConfirmed, today.AddDate(0, 0, -1), true},
+		{"cancelled", model.StatusCancelled, today.AddDate(0, 0, 1), true},
+		{"checked in", model.StatusCheckedIn, today, true},
+		{"rejected", model.StatusRejected, today.AddDate(0, 0, 1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exchangeable(&model.Reservation{Status: tt.status, Date: tt.date}, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("exchangeable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSwapExchangesReservations(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	swaps := newTestSwapService(service)
+	reservations := bookTestReservations(t, service, 2)
+	offered, wanted := reservations[0], reservations[1]
+	ctx := context.Background()
+
+	swap := &model.ReservationSwap{ReservationID: offered.ID, TargetReservationID: wanted.ID, ProposerID: wanted.UserID}
+	if err := swaps.ProposeSwap(ctx, swap); !errors.Is(err, ErrFoodtruckForbidden) {
+		t.Fatalf("expected only the offered truck to propose, got %v", err)
+	}
+
+	swap.ProposerID = offered.UserID
+	if err := swaps.ProposeSwap(ctx, swap); err != nil {
+		t.Fatalf("failed to propose swap: %v", err)
+	}
+	if _, err := swaps.RespondToSwap(ctx, swap.ID, offered.UserID, true); err == nil {
+		t.Fatal("expected the proposer not to accept its own swap")
+	}
+
+	accepted, err := swaps.RespondToSwap(ctx, swap.ID, wanted.UserID, true)
+	if err != nil {
+		t.Fatalf("failed to accept swap: %v", err)
+	}
+	if accepted.Status != model.SwapAccepted {
+		t.Fatalf("expected the swap to be accepted, got %s", accepted.Status)
+	}
+
+	first, _ := swaps.Reservations.GetReservationByID(ctx, offered.ID, primitive.NilObjectID)
+	second, _ := swaps.Reservations.GetReservationByID(ctx, wanted.ID, primitive.NilObjectID)
+	if first.FoodTruckID != wanted.FoodTruckID || first.UserID != wanted.UserID || second.FoodTruckID != offered.FoodTruckID || second.UserID != offered.UserID {
+		t.Fatalf("expected the food trucks to be exchanged, got %+v and %+v", first, second)
+	}
+	if _, err := swaps.RespondToSwap(ctx, swap.ID, wanted.UserID, true); err == nil {
+		t.Fatal("expected a swap to be accepted once")
+	}
+}
+
+func TestSwapRechecksWeeklyQuota(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	swaps := newTestSwapService(service)
+	reservations := bookTestReservations(t, service, 2)
+	offered, wanted := reservations[0], reservations[1]
+	ctx := context.Background()
+
+	// The offering truck already serves the week of the wanted reservation
+	extra := model.Reservation{ID: primitive.NewObjectID(), SpotID: wanted.SpotID, FoodTruckID: offered.FoodTruckID, UserID: offered.UserID, SpotNumber: 1, Date: wanted.Date, Status: model.StatusConfirmed}
+	if _, err := swaps.Reservations.ReservationCollection.InsertOne(ctx, extra); err != nil {
+		t.Fatalf("failed to insert reservation: %v", err)
+	}
+
+	swap := &model.ReservationSwap{ReservationID: offered.ID, TargetReservationID: wanted.ID, ProposerID: offered.UserID}
+	err := swaps.ProposeSwap(ctx, swap)
+	var violation *PolicyViolation
+	if !errors.As(err, &violation) || violation.Rule != RuleWeeklyQuota {
+		t.Fatalf("expected the weekly quota to refuse the swap, got %v", err)
+	}
+}
+
+func TestTransferReservationToSameOwner(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	reservations := bookTestReservations(t, service, 2)
+	reservation, other := reservations[0], reservations[1]
+	ctx := context.Background()
+
+	sibling := model.Foodtruck{ID: primitive.NewObjectID(), Name: "Second truck", UserID: reservation.UserID}
+	if _, err := service.FoodtruckCollection.InsertOne(ctx, sibling); err != nil {
+		t.Fatalf("failed to insert food truck: %v", err)
+	}
+
+	if _, err := service.TransferReservation(ctx, reservation.ID, other.FoodTruckID, reservation.UserID); err == nil {
+		t.Fatal("expected a transfer to another owner's truck to be refused")
+	}
+
+	transferred, err := service.TransferReservation(ctx, reservation.ID, sibling.ID, reservation.UserID)
+	if err != nil {
+		t.Fatalf("failed to transfer reservation: %v", err)
+	}
+	var stored model.Reservation
+	if err := service.ReservationCollection.FindOne(ctx, bson.M{"_id": reservation.ID}).Decode(&stored); err != nil {
+		t.Fatalf("failed to fetch reservation: %v", err)
+	}
+	if transferred.FoodTruckID != sibling.ID || stored.FoodTruckID != sibling.ID || stored.FoodTruckName != sibling.Name {
+		t.Fatalf("expected the reservation to belong to the second truck, got %+v", stored)
+	}
+}
+
+func TestSwapRefusedToBannedUser(t *testing.T) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	swaps := newTestSwapService(service)
+	reservations := bookTestReservations(t, service, 2)
+	offered, wanted := reservations[0], reservations[1]
+	ctx := context.Background()
+
+	// The user taking the offered reservation is serving a booking ban
+	until := time.Now().Add(24 * time.Hour)
+	if _, err := service.UserCollection.UpdateOne(ctx, bson.M{"_id": wanted.UserID}, bson.M{"$set": bson.M{"booking_banned_until": until}}); err != nil {
+		t.Fatalf("failed to ban user: %v", err)
+	}
+
+	swap := &model.ReservationSwap{ReservationID: offered.ID, TargetReservationID: wanted.ID, ProposerID: offered.UserID}
+	if err := swaps.ProposeSwap(ctx, swap); err == nil {
+		t.Fatal("expected a banned user to be refused a swap")
+	}
+}
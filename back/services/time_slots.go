@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// parseClock converts an HH:MM time of day into minutes since midnight
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// validateSlots checks that slots have unique IDs, end after they start, do not overlap and fit the layout
+func validateSlots(slots []model.TimeSlot, spotCount int) error {
+	type window struct{ start, end int }
+	windows := make(map[string]window, len(slots))
+
+	for _, slot := range slots {
+		if slot.ID == "" {
+			return errors.New("every slot needs an id")
+		}
+		if _, ok := windows[slot.ID]; ok {
+			return fmt.Errorf("duplicate slot %q", slot.ID)
+		}
+
+		start, err := parseClock(slot.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(slot.End)
+		if err != nil {
+			return err
+		}
+		if end <= start {
+			return fmt.Errorf("slot %q must end after it starts", slot.ID)
+		}
+		if slot.MaxCapacity < 0 || slot.MaxCapacity > spotCount {
+			return fmt.Errorf("slot %q: max_capacity must be between 0 and %d", slot.ID, spotCount)
+		}
+
+		for id, other := range windows {
+			if start < other.end && other.start < end {
+				return fmt.Errorf("slots %q and %q overlap", id, slot.ID)
+			}
+		}
+		windows[slot.ID] = window{start, end}
+	}
+
+	return nil
+}
+
+// resolveSlot finds the slot a booking refers to: none for parking spots booked by whole days, and a slot of the
+// parking spot otherwise
+func resolveSlot(spot *model.ParkingSpot, slotID string) (*model.TimeSlot, error) {
+	if len(spot.Slots) == 0 {
+		if slotID != "" {
+			return nil, fmt.Errorf("spot is booked for whole days, slot %q does not exist", slotID)
+		}
+		return nil, nil
+	}
+
+	if slotID == "" {
+		return nil, errors.New("slot_id is required for this day")
+	}
+	for i := range spot.Slots {
+		if spot.Slots[i].ID == slotID {
+			return &spot.Slots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("slot %q does not exist for this day", slotID)
+}
+
+// spotSlots lists the bookable slots of a parking spot, a single nil slot standing for the whole day
+func spotSlots(spot *model.ParkingSpot) []*model.TimeSlot {
+	if len(spot.Slots) == 0 {
+		return []*model.TimeSlot{nil}
+	}
+
+	slots := make([]*model.TimeSlot, len(spot.Slots))
+	for i := range spot.Slots {
+		slots[i] = &spot.Slots[i]
+	}
+	return slots
+}
+
+// slotKey returns the key of a slot, empty for the whole day
+func slotKey(slot *model.TimeSlot) string {
+	if slot == nil {
+		return ""
+	}
+	return slot.ID
+}
+
+// slotCapacity returns how many trucks a slot of a parking spot can host
+func slotCapacity(spot *model.ParkingSpot, slot *model.TimeSlot) int {
+	if slot != nil && slot.MaxCapacity > 0 {
+		return slot.MaxCapacity
+	}
+	return spot.MaxCapacity
+}
+
+// slotFilter matches the given slot IDs on documents where an empty slot may have been omitted
+func slotFilter(slotIDs ...string) bson.M {
+	values := make([]interface{}, 0, len(slotIDs)+1)
+	for _, id := range slotIDs {
+		values = append(values, id)
+		if id == "" {
+			values = append(values, nil)
+		}
+	}
+	return bson.M{"$in": values}
+}
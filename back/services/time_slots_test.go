@@ -0,0 +1,94 @@
+package services
+
+import (
+	"gitlab.com/hooly2/back/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateSlots(t *testing.T) {
+	lunch := model.TimeSlot{ID: "lunch", Start: "11:00", End: "15:00"}
+	dinner := model.TimeSlot{ID: "dinner", Start: "18:00", End: "22:00"}
+
+	tests := []struct {
+		name    string
+		slots   []model.TimeSlot
+		wantErr bool
+	}{
+		{"no slots", nil, false},
+		{"lunch and dinner", []model.TimeSlot{lunch, dinner}, false},
+		{"adjacent slots", []model.TimeSlot{lunch, {ID: "afternoon", Start: "15:00", End: "18:00"}}, false},
+		{"slot capacity", []model.TimeSlot{{ID: "lunch", Start: "11:00", End: "15:00", MaxCapacity: 3}}, false},
+		{"missing id", []model.TimeSlot{{Start: "11:00", End: "15:00"}}, true},
+		{"duplicate id", []model.TimeSlot{lunch, {ID: "lunch", Start: "18:00", End: "22:00"}}, true},
+		{"invalid time", []model.TimeSlot{{ID: "lunch", Start: "11h", End: "15:00"}}, true},
+		{"ends before it starts", []model.TimeSlot{{ID: "lunch", Start: "15:00", End: "11:00"}}, true},
+		{"overlapping slots", []model.TimeSlot{lunch, {ID: "brunch", Start: "10:00", End: "12:00"}}, true},
+		{"capacity above spot count", []model.TimeSlot{{ID: "lunch", Start: "11:00", End: "15:00", MaxCapacity: 4}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSlots(tt.slots, 3); (err != nil) != tt.wantErr {
+				t.Fatalf("validateSlots() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveSlot(t *testing.T) {
+	wholeDay := &model.ParkingSpot{MaxCapacity: 3}
+	if slot, err := resolveSlot(wholeDay, ""); err != nil || slot != nil {
+		t.Fatalf("expected the whole day, got %v, %v", slot, err)
+	}
+	if _, err := resolveSlot(wholeDay, "lunch"); err == nil {
+		t.Fatal("expected an error for a slot on a whole day spot")
+	}
+
+	slotted := &model.ParkingSpot{MaxCapacity: 3, Slots: []model.TimeSlot{
+		{ID: "lunch", Start: "11:00", End: "15:00", MaxCapacity: 2},
+		{ID: "dinner", Start: "18:00", End: "22:00"},
+	}}
+	if _, err := resolveSlot(slotted, ""); err == nil {
+		t.Fatal("expected the slot to be required")
+	}
+	if _, err := resolveSlot(slotted, "breakfast"); err == nil {
+		t.Fatal("expected an error for an unknown slot")
+	}
+
+	lunch, err := resolveSlot(slotted, "lunch")
+	if err != nil || lunch.ID != "lunch" {
+		t.Fatalf("expected the lunch slot, got %v, %v", lunch, err)
+	}
+	if got := slotCapacity(slotted, lunch); got != 2 {
+		t.Fatalf("expected lunch capacity 2, got %d", got)
+	}
+	dinner, _ := resolveSlot(slotted, "dinner")
+	if got := slotCapacity(slotted, dinner); got != 3 {
+		t.Fatalf("expected dinner to default to the spot capacity, got %d", got)
+	}
+}
+
+func TestSlotAvailability(t *testing.T) {
+	date := time.Date(2030, 1, 4, 0, 0, 0, 0, time.UTC)
+	spot := &model.ParkingSpot{Day: "Friday", MaxCapacity: 3, SpotNumbers: []int{1, 2, 3}, Slots: []model.TimeSlot{
+		{ID: "lunch", Start: "11:00", End: "15:00", MaxCapacity: 2},
+		{ID: "dinner", Start: "18:00", End: "22:00"},
+	}}
+	location := &model.Location{}
+
+	// The same spot number is taken at lunch and free at dinner
+	lunch := slotAvailability(spot, location, date, &spot.Slots[0], model.SpotOccupancy{ReservedCount: 1, TakenSpots: []int{1}}, []int{}, nil)
+	if lunch.MaxCapacity != 2 || lunch.RemainingCapacity != 1 || !reflect.DeepEqual(lunch.FreeSpots, []int{2, 3}) {
+		t.Fatalf("unexpected lunch availability: %+v", lunch)
+	}
+
+	dinner := slotAvailability(spot, location, date, &spot.Slots[1], model.SpotOccupancy{}, []int{3}, nil)
+	if dinner.MaxCapacity != 3 || dinner.RemainingCapacity != 2 || !reflect.DeepEqual(dinner.FreeSpots, []int{1, 2}) {
+		t.Fatalf("unexpected dinner availability: %+v", dinner)
+	}
+	if dinner.Slot.ID != "dinner" || !reflect.DeepEqual(dinner.TakenSpots, []int{}) {
+		t.Fatalf("unexpected dinner slot: %+v", dinner)
+	}
+}
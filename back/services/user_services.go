@@ -12,12 +12,16 @@ import (
 )
 
 type UserService struct {
-	UserCollection *mongo.Collection
+	UserCollection      *mongo.Collection
+	FoodtruckCollection *mongo.Collection
+	Reservations        *ReservationService // Cascades deletions to reservations
 }
 
 func NewUserService() *UserService {
 	return &UserService{
-		UserCollection: db.GetCollection("user"),
+		UserCollection:      db.GetCollection("user"),
+		FoodtruckCollection: db.GetCollection("foodtruck"),
+		Reservations:        NewReservationService(),
 	}
 }
 
@@ -156,17 +160,117 @@ func (s *UserService) UpdateUser(userID string, updatedUser model.User) (*model.
 	return &user, nil
 }
 
-// DeleteUser deletes a user from the collection (admin only)
-func (s *UserService) DeleteUser(userID string) error {
+// DeleteUser deletes a user from the collection (admin only). The future reservations, holds and waitlist entries
+// of the user and of their foodtrucks are handled according to the cascade mode, then their foodtrucks are deleted,
+// or given to another user in transfer mode.
+func (s *UserService) DeleteUser(ctx context.Context, userID string, cascade CascadeOptions) (*DeletionReport, error) {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return errors.New("invalid user ID format")
+		return nil, errors.New("invalid user ID format")
 	}
 
-	// Delete the user from the collection
-	_, err = s.UserCollection.DeleteOne(context.TODO(), bson.M{"_id": objectID})
+	if count, err := s.UserCollection.CountDocuments(ctx, bson.M{"_id": objectID}); err != nil {
+		return nil, err
+	} else if count == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	report, err := s.Reservations.newDeletionReport(ctx, &cascade, objectID)
 	if err != nil {
-		return errors.New("failed to delete user")
+		return nil, err
+	}
+
+	cursor, err := s.FoodtruckCollection.Find(ctx, bson.M{"user_id": objectID})
+	if err != nil {
+		return nil, errors.New("failed to fetch foodtrucks")
+	}
+	var foodtrucks []model.Foodtruck
+	if err := cursor.All(ctx, &foodtrucks); err != nil {
+		return nil, errors.New("failed to fetch foodtrucks")
+	}
+	foodtruckIDs := make([]primitive.ObjectID, 0, len(foodtrucks))
+	for _, foodtruck := range foodtrucks {
+		foodtruckIDs = append(foodtruckIDs, foodtruck.ID)
+	}
+
+	// Staff members may have booked for the user's foodtrucks, and the user for foodtrucks they are staff of
+	ownFilter := bson.M{"food_truck_id": bson.M{"$in": foodtruckIDs}}
+	staffFilter := bson.M{"user_id": objectID, "food_truck_id": bson.M{"$nin": foodtruckIDs}}
+	if cascade.Mode != CascadeTransfer {
+		filter := bson.M{"$or": []bson.M{ownFilter, staffFilter}}
+		if err := s.Reservations.cascadeDeletion(ctx, filter, &cascade, "Account deleted", report); err != nil {
+			return report, err
+		}
+	} else {
+		if err := s.Reservations.cascadeDeletion(ctx, ownFilter, &cascade, "Account deleted", report); err != nil {
+			return report, err
+		}
+		if err := s.returnStaffBookings(ctx, staffFilter, cascade, report); err != nil {
+			return report, err
+		}
+	}
+
+	if len(foodtruckIDs) > 0 {
+		if cascade.Mode == CascadeTransfer {
+			_, err = s.FoodtruckCollection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": foodtruckIDs}}, bson.M{"$set": bson.M{"user_id": cascade.TransferTo}})
+			if err != nil {
+				return report, errors.New("failed to transfer foodtrucks")
+			}
+			report.TransferredFoodtrucks = foodtruckIDs
+		} else {
+			if _, err = s.FoodtruckCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": foodtruckIDs}}); err != nil {
+				return report, errors.New("failed to delete foodtrucks")
+			}
+			report.DeletedFoodtrucks = foodtruckIDs
+		}
+	}
+
+	// The user no longer books for the foodtrucks they were staff of
+	if _, err = s.FoodtruckCollection.UpdateMany(ctx, bson.M{"staff_ids": objectID}, bson.M{"$pull": bson.M{"staff_ids": objectID}}); err != nil {
+		return report, errors.New("failed to remove user from foodtruck staff")
+	}
+
+	// Delete the user from the collection
+	if _, err = s.UserCollection.DeleteOne(ctx, bson.M{"_id": objectID}); err != nil {
+		return report, errors.New("failed to delete user")
+	}
+	report.Deleted = true
+
+	return report, nil
+}
+
+// returnStaffBookings gives what a deleted user booked as staff of other owners' foodtrucks back to those owners.
+// Bookings for foodtrucks that no longer exist are cancelled.
+func (s *UserService) returnStaffBookings(ctx context.Context, filter bson.M, cascade CascadeOptions, report *DeletionReport) error {
+	foodtruckIDs := map[primitive.ObjectID]bool{}
+	for _, collection := range []*mongo.Collection{s.Reservations.ReservationCollection, s.Reservations.HoldCollection, s.Reservations.WaitlistCollection} {
+		ids, err := collection.Distinct(ctx, "food_truck_id", filter)
+		if err != nil {
+			return errors.New("failed to fetch staff bookings")
+		}
+		for _, id := range ids {
+			if foodtruckID, ok := id.(primitive.ObjectID); ok {
+				foodtruckIDs[foodtruckID] = true
+			}
+		}
+	}
+
+	for foodtruckID := range foodtruckIDs {
+		foodtruck, err := findFoodtruck(ctx, s.FoodtruckCollection, foodtruckID)
+		if err != nil {
+			return err
+		}
+
+		owner := cascade
+		if foodtruck.ID.IsZero() {
+			owner.Mode = CascadeCancel
+		} else {
+			owner.TransferTo = foodtruck.UserID
+		}
+		bookings := bson.M{"user_id": filter["user_id"], "food_truck_id": foodtruckID}
+		if err := s.Reservations.cascadeDeletion(ctx, bookings, &owner, "Account deleted", report); err != nil {
+			return err
+		}
 	}
 
 	return nil
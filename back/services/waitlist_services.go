@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/hooly2/back/db"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// WaitlistService queues food trucks for full dates and books them when a spot is released
+type WaitlistService struct {
+	WaitlistCollection *mongo.Collection
+	Reservations       *ReservationService
+}
+
+func NewWaitlistService(reservationService *ReservationService) *WaitlistService {
+	return &WaitlistService{
+		WaitlistCollection: db.GetCollection("waitlist"),
+		Reservations:       reservationService,
+	}
+}
+
+// JoinWaitlist queues a food truck for a date and slot, optionally for a specific spot number. The date must be one the
+// booking policy lets the user book.
+func (s *WaitlistService) JoinWaitlist(ctx context.Context, entry *model.WaitlistEntry) error {
+	entry.Date = utils.TruncateToDay(entry.Date)
+
+	// Only trucks the user may book for can wait for a spot
+	if _, err := s.Reservations.bookableFoodtruck(ctx, entry.FoodTruckID, entry.UserID); err != nil {
+		return err
+	}
+
+	location, err := s.Reservations.Locations.GetLocation(ctx, entry.LocationID)
+	if err != nil {
+		return err
+	}
+	if entry.Date.Before(locationToday(location, time.Now())) {
+		return errors.New("cannot join the waitlist for a past date")
+	}
+
+	// Waiting only makes sense for a date the booking policy allows: lead time, horizon, blackout days and quotas
+	reservation := &model.Reservation{
+		LocationID:  location.ID,
+		FoodTruckID: entry.FoodTruckID,
+		UserID:      entry.UserID,
+		SpotNumber:  entry.SpotNumber,
+		Date:        entry.Date,
+		SlotID:      entry.SlotID,
+	}
+	if err := s.Reservations.checkBookingPolicy(ctx, reservation, location); err != nil {
+		return err
+	}
+
+	// Find the parking spot of the location open on that weekday
+	var parkingSpot model.ParkingSpot
+	err = s.Reservations.ParkingSpotCollection.FindOne(ctx, bson.M{"location_id": location.ID, "day_of_week": entry.Date.Weekday().String()}).Decode(&parkingSpot)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("spot is not available on this day")
+		}
+		return err
+	}
+
+	if entry.SpotNumber != 0 && !containsInt(parkingSpot.SpotNumbers, entry.SpotNumber) {
+		return fmt.Errorf("spot number %d does not exist", entry.SpotNumber)
+	}
+
+	slot, err := resolveSlot(&parkingSpot, entry.SlotID)
+	if err != nil {
+		return err
+	}
+
+	// Nothing will be released on a closed date
+	if err := s.Reservations.Closures.CheckOpen(ctx, location.ID, entry.Date, entry.SpotNumber); err != nil {
+		return err
+	}
+
+	// Joining only makes sense when the wanted spot cannot be booked right now
+	occupancy, err := s.Reservations.Occupancy.GetOccupancy(ctx, parkingSpot.ID, entry.Date, entry.SlotID)
+	if err != nil {
+		return err
+	}
+	full := occupancy.ReservedCount >= slotCapacity(&parkingSpot, slot)
+	if !full && (entry.SpotNumber == 0 || !containsInt(occupancy.TakenSpots, entry.SpotNumber)) {
+		return errors.New("spot is still available, book it directly")
+	}
+
+	// A food truck queues only once per date and slot
+	count, err := s.WaitlistCollection.CountDocuments(ctx, bson.M{
+		"food_truck_id": entry.FoodTruckID,
+		"date":          entry.Date,
+		"slot_id":       slotFilter(entry.SlotID),
+		"status":        bson.M{"$in": []string{model.WaitlistWaiting, model.WaitlistPromoting}},
+	})
+	if err != nil {
+		return errors.New("failed to check waitlist")
+	}
+	if count > 0 {
+		return errors.New("food truck is already on the waitlist for this day")
+	}
+
+	entry.ID = primitive.NewObjectID()
+	entry.LocationID = location.ID
+	entry.SpotID = parkingSpot.ID
+	entry.Status = model.WaitlistWaiting
+	entry.CreatedAt = time.Now()
+
+	_, err = s.WaitlistCollection.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to join waitlist: %v", err)
+	}
+
+	return nil
+}
+
+// GetUserWaitlist retrieves the waitlist entries of a user
+func (s *WaitlistService) GetUserWaitlist(ctx context.Context, userID primitive.ObjectID) ([]model.WaitlistEntry, error) {
+	return s.findEntries(ctx, bson.M{"user_id": userID})
+}
+
+// GetWaitlist retrieves every waitlist entry at the given locations, every location when none are given, optionally
+// for a single date (admin use case)
+func (s *WaitlistService) GetWaitlist(ctx context.Context, date time.Time, locationIDs []primitive.ObjectID) ([]model.WaitlistEntry, error) {
+	filter := bson.M{}
+	if !date.IsZero() {
+		filter["date"] = utils.TruncateToDay(date)
+	}
+	if len(locationIDs) > 0 {
+		filter["location_id"] = bson.M{"$in": locationIDs}
+	}
+	return s.findEntries(ctx, filter)
+}
+
+// LeaveWaitlist cancels a waiting entry, scoped by user ID when given
+func (s *WaitlistService) LeaveWaitlist(ctx context.Context, entryID primitive.ObjectID, userID primitive.ObjectID) error {
+	filter := bson.M{"_id": entryID, "status": model.WaitlistWaiting}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	now := time.Now()
+	result, err := s.WaitlistCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"status":      model.WaitlistCancelled,
+		"resolved_at": now,
+	}})
+	if err != nil {
+		return errors.New("failed to leave waitlist")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("waitlist entry not found")
+	}
+
+	return nil
+}
+
+// PromoteNext books the first eligible waiting food truck onto a spot number that was just released in a slot.
+// Entries are tried in arrival order; the outcome of each attempt is recorded on the entry.
+func (s *WaitlistService) PromoteNext(ctx context.Context, spotID primitive.ObjectID, date time.Time, slotID string, spotNumber int) {
+	date = utils.TruncateToDay(date)
+
+	// A spot released by a closure cannot be booked again
+	var parkingSpot model.ParkingSpot
+	if err := s.Reservations.ParkingSpotCollection.FindOne(ctx, bson.M{"_id": spotID}).Decode(&parkingSpot); err != nil {
+		log.Println("Error fetching parking spot:", err)
+		return
+	}
+	if err := s.Reservations.Closures.CheckOpen(ctx, parkingSpot.LocationID, date, spotNumber); err != nil {
+		return
+	}
+
+	filter := bson.M{
+		"spot_id":     spotID,
+		"date":        date,
+		"slot_id":     slotFilter(slotID),
+		"status":      model.WaitlistWaiting,
+		"spot_number": bson.M{"$in": []interface{}{0, spotNumber, nil}}, // Entries for any spot stored before 0 was kept have no spot_number
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	entries, err := s.findEntries(ctx, filter, opts)
+	if err != nil {
+		log.Println("Error fetching waitlist:", err)
+		return
+	}
+
+	for _, entry := range entries {
+		// Claim the entry so that concurrent releases do not promote it twice
+		claim, err := s.WaitlistCollection.UpdateOne(ctx,
+			bson.M{"_id": entry.ID, "status": model.WaitlistWaiting},
+			bson.M{"$set": bson.M{"status": model.WaitlistPromoting}})
+		if err != nil || claim.ModifiedCount == 0 {
+			continue
+		}
+
+		reservation := model.Reservation{
+			SpotID:      spotID,
+			FoodTruckID: entry.FoodTruckID,
+			UserID:      entry.UserID,
+			SpotNumber:  spotNumber,
+			SlotID:      slotID,
+			Date:        date,
+		}
+		if err := s.Reservations.CreateReservation(ctx, &reservation); err != nil {
+			// Not eligible right now, keep the entry queued and move on
+			s.recordOutcome(ctx, entry.ID, bson.M{
+				"status":  model.WaitlistWaiting,
+				"outcome": fmt.Sprintf("spot %d released but not booked: %v", spotNumber, err),
+			})
+			if errors.Is(err, ErrSpotConflict) || errors.Is(err, ErrSpotFull) {
+				return
+			}
+			continue
+		}
+
+		s.recordOutcome(ctx, entry.ID, bson.M{
+			"status":         model.WaitlistPromoted,
+			"reservation_id": reservation.ID,
+			"outcome":        fmt.Sprintf("booked spot %d", spotNumber),
+			"resolved_at":    time.Now(),
+		})
+		return
+	}
+}
+
+// BackfillSpotNumbers stores the spot number 0 on the entries for any spot that were saved without one
+func (s *WaitlistService) BackfillSpotNumbers(ctx context.Context) error {
+	_, err := s.WaitlistCollection.UpdateMany(ctx, bson.M{"spot_number": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"spot_number": 0}})
+	if err != nil {
+		return fmt.Errorf("failed to backfill waitlist spot numbers: %v", err)
+	}
+	return nil
+}
+
+// recordOutcome stores the result of a promotion attempt on a waitlist entry
+func (s *WaitlistService) recordOutcome(ctx context.Context, entryID primitive.ObjectID, fields bson.M) {
+	if _, err := s.WaitlistCollection.UpdateOne(ctx, bson.M{"_id": entryID}, bson.M{"$set": fields}); err != nil {
+		log.Println("Error recording waitlist outcome:", err)
+	}
+}
+
+// findEntries runs a waitlist query and decodes the results
+func (s *WaitlistService) findEntries(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]model.WaitlistEntry, error) {
+	cursor, err := s.WaitlistCollection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []model.WaitlistEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// containsString reports whether value is in values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInt reports whether value is in values
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/hooly2/back/model"
+	"gitlab.com/hooly2/back/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+// newTestWaitlist fills the only spot number of a future date and returns the waitlist with the reservation
+// holding it. Released spot numbers are offered to the waitlist.
+func newTestWaitlist(t *testing.T) (*WaitlistService, *model.Reservation) {
+	service := newTestReservationService(t, newTestDatabase(t))
+	waitlist := &WaitlistService{WaitlistCollection: service.WaitlistCollection, Reservations: service}
+	service.OnRelease = waitlist.PromoteNext
+
+	date := utils.TruncateToDay(time.Now().Add(72 * time.Hour))
+	spotID := insertTestParkingSpot(t, service, date, []int{1})
+	foodTruckID, userID := insertTestFoodtruck(t, service)
+	reservation := &model.Reservation{SpotID: spotID, FoodTruckID: foodTruckID, UserID: userID, SpotNumber: 1, Date: date}
+	if err := service.CreateReservation(context.Background(), reservation); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+	return waitlist, reservation
+}
+
+func TestPromoteNextBooksEntryForAnySpot(t *testing.T) {
+	waitlist, reservation := newTestWaitlist(t)
+	ctx := context.Background()
+
+	foodTruckID, userID := insertTestFoodtruck(t, waitlist.Reservations)
+	entry := &model.WaitlistEntry{FoodTruckID: foodTruckID, UserID: userID, Date: reservation.Date}
+	if err := waitlist.JoinWaitlist(ctx, entry); err != nil {
+		t.Fatalf("failed to join waitlist: %v", err)
+	}
+
+	if _, err := waitlist.Reservations.ChangeReservationStatus(ctx, reservation.ID, reservation.UserID, reservation.UserID, model.StatusCancelled, ""); err != nil {
+		t.Fatalf("failed to cancel reservation: %v", err)
+	}
+
+	var promoted model.WaitlistEntry
+	if err := waitlist.WaitlistCollection.FindOne(ctx, bson.M{"_id": entry.ID}).Decode(&promoted); err != nil {
+		t.Fatalf("failed to fetch waitlist entry: %v", err)
+	}
+	if promoted.Status != model.WaitlistPromoted || promoted.ReservationID.IsZero() {
+		t.Fatalf("expected the entry to be promoted, got %s (%s)", promoted.Status, promoted.Outcome)
+	}
+}
+
+func TestJoinWaitlistChecksBookingPolicy(t *testing.T) {
+	waitlist, reservation := newTestWaitlist(t)
+	ctx := context.Background()
+
+	policy := &model.BookingPolicy{LeadTimeHours: 96}
+	if err := waitlist.Reservations.Settings.UpdateBookingPolicy(ctx, policy, primitive.NewObjectID()); err != nil {
+		t.Fatalf("failed to save booking policy: %v", err)
+	}
+
+	foodTruckID, userID := insertTestFoodtruck(t, waitlist.Reservations)
+	entry := &model.WaitlistEntry{FoodTruckID: foodTruckID, UserID: userID, Date: reservation.Date}
+	var violation *PolicyViolation
+	if err := waitlist.JoinWaitlist(ctx, entry); !errors.As(err, &violation) || violation.Rule != RuleLeadTime {
+		t.Fatalf("expected a lead time violation, got %v", err)
+	}
+}
+
+func TestPromoteNextBooksEntryWithoutSpotNumber(t *testing.T) {
+	waitlist, reservation := newTestWaitlist(t)
+	ctx := context.Background()
+
+	// Entries for any spot used to be stored without a spot_number field
+	foodTruckID, userID := insertTestFoodtruck(t, waitlist.Reservations)
+	entryID := primitive.NewObjectID()
+	_, err := waitlist.WaitlistCollection.InsertOne(ctx, bson.M{
+		"_id":           entryID,
+		"spot_id":       reservation.SpotID,
+		"food_truck_id": foodTruckID,
+		"user_id":       userID,
+		"date":          reservation.Date,
+		"status":        model.WaitlistWaiting,
+		"created_at":    time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("failed to insert waitlist entry: %v", err)
+	}
+
+	waitlist.PromoteNext(ctx, reservation.SpotID, reservation.Date, "", 2)
+	var entry model.WaitlistEntry
+	if err := waitlist.WaitlistCollection.FindOne(ctx, bson.M{"_id": entryID}).Decode(&entry); err != nil {
+		t.Fatalf("failed to fetch waitlist entry: %v", err)
+	}
+	if entry.Status != model.WaitlistWaiting {
+		t.Fatalf("expected a spot number outside the spot to leave the entry waiting, got %s", entry.Status)
+	}
+
+	if _, err := waitlist.Reservations.ChangeReservationStatus(ctx, reservation.ID, reservation.UserID, reservation.UserID, model.StatusCancelled, ""); err != nil {
+		t.Fatalf("failed to cancel reservation: %v", err)
+	}
+	if err := waitlist.WaitlistCollection.FindOne(ctx, bson.M{"_id": entryID}).Decode(&entry); err != nil {
+		t.Fatalf("failed to fetch waitlist entry: %v", err)
+	}
+	if entry.Status != model.WaitlistPromoted {
+		t.Fatalf("expected the legacy entry to be promoted, got %s (%s)", entry.Status, entry.Outcome)
+	}
+}
+
+func TestBackfillSpotNumbers(t *testing.T) {
+	waitlist, reservation := newTestWaitlist(t)
+	ctx := context.Background()
+
+	entryID := primitive.NewObjectID()
+	if _, err := waitlist.WaitlistCollection.InsertOne(ctx, bson.M{"_id": entryID, "spot_id": reservation.SpotID, "date": reservation.Date, "status": model.WaitlistWaiting}); err != nil {
+		t.Fatalf("failed to insert waitlist entry: %v", err)
+	}
+	if err := waitlist.BackfillSpotNumbers(ctx); err != nil {
+		t.Fatalf("failed to backfill: %v", err)
+	}
+	if count, _ := waitlist.WaitlistCollection.CountDocuments(ctx, bson.M{"_id": entryID, "spot_number": 0}); count != 1 {
+		t.Fatal("expected the entry to be stored with the spot number 0")
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CheckinToken is the content of a signed check-in token
+type CheckinToken struct {
+	ReservationID string
+	Date          string // Reservation date as YYYY-MM-DD
+	SpotNumber    int
+}
+
+// ErrCheckinSecretMissing is returned when check-in tokens are signed or verified without CHECKIN_SECRET
+var ErrCheckinSecretMissing = errors.New("CHECKIN_SECRET is not set")
+
+// CheckinSecret returns the key signing check-in tokens, read from the CHECKIN_SECRET environment variable
+func CheckinSecret() ([]byte, error) {
+	secret := os.Getenv("CHECKIN_SECRET")
+	if secret == "" {
+		return nil, ErrCheckinSecretMissing
+	}
+	return []byte(secret), nil
+}
+
+// signCheckinPayload returns the URL-safe HMAC-SHA256 signature of payload
+func signCheckinPayload(payload string) (string, error) {
+	secret, err := CheckinSecret()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// GenerateCheckinToken builds a signed token bound to a reservation, its date and its spot number
+func GenerateCheckinToken(reservationID, date string, spotNumber int) (string, error) {
+	payload := fmt.Sprintf("%s.%s.%d", reservationID, date, spotNumber)
+	signature, err := signCheckinPayload(payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + signature, nil
+}
+
+// ParseCheckinToken verifies the signature of a check-in token and returns its content
+func ParseCheckinToken(token string) (*CheckinToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return nil, errors.New("malformed check-in token")
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	signature, err := signCheckinPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(parts[3]), []byte(signature)) {
+		return nil, errors.New("invalid check-in token signature")
+	}
+
+	spotNumber, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed check-in token")
+	}
+
+	return &CheckinToken{ReservationID: parts[0], Date: parts[1], SpotNumber: spotNumber}, nil
+}
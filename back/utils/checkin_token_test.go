@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckinToken(t *testing.T) {
+	t.Setenv("CHECKIN_SECRET", "test-secret")
+	token, err := GenerateCheckinToken("64b7f0c2a1b2c3d4e5f60718", "2026-11-03", 3)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	parsed, err := ParseCheckinToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if parsed.ReservationID != "64b7f0c2a1b2c3d4e5f60718" || parsed.Date != "2026-11-03" || parsed.SpotNumber != 3 {
+		t.Fatalf("unexpected token content %+v", parsed)
+	}
+
+	tampered := token[:len(token)-1] + "A"
+	if tampered == token {
+		tampered = token[:len(token)-1] + "B"
+	}
+	if _, err := ParseCheckinToken(tampered); err == nil {
+		t.Fatal("expected a tampered token to be rejected")
+	}
+
+	forged := "64b7f0c2a1b2c3d4e5f60718.2026-11-04.3." + token[strings.LastIndex(token, ".")+1:]
+	if _, err := ParseCheckinToken(forged); err == nil {
+		t.Fatal("expected a token with a changed date to be rejected")
+	}
+}
+
+func TestCheckinTokenRequiresSecret(t *testing.T) {
+	t.Setenv("CHECKIN_SECRET", "test-secret")
+	token, err := GenerateCheckinToken("64b7f0c2a1b2c3d4e5f60718", "2026-11-03", 3)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	t.Setenv("CHECKIN_SECRET", "")
+	if _, err := GenerateCheckinToken("64b7f0c2a1b2c3d4e5f60718", "2026-11-03", 3); !errors.Is(err, ErrCheckinSecretMissing) {
+		t.Fatalf("expected signing without a secret to be refused, got %v", err)
+	}
+	if _, err := ParseCheckinToken(token); !errors.Is(err, ErrCheckinSecretMissing) {
+		t.Fatalf("expected verifying without a secret to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// DateLayout is the calendar date format accepted in query parameters
+const DateLayout = "2006-01-02"
+
+// TruncateToDay returns midnight UTC of the calendar date of t
+func TruncateToDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate parses a calendar date (YYYY-MM-DD) or an RFC3339 timestamp and truncates it to the day
+func ParseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(DateLayout, value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date, expected YYYY-MM-DD")
+	}
+	return TruncateToDay(date), nil
+}
+
+// ParseWeekday converts an English day name (e.g. "Monday") into a time.Weekday
+func ParseWeekday(value string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day.String() == value {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// WeekBounds returns the calendar week containing date as [start, end), weeks starting on weekStart
+func WeekBounds(date time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	date = TruncateToDay(date)
+	offset := (int(date.Weekday()) - int(weekStart) + 7) % 7
+	start := date.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// MonthBounds returns the calendar month containing date as [start, end)
+func MonthBounds(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		date      time.Time
+		weekStart time.Weekday
+		start     time.Time
+	}{
+		{
+			name:      "ISO week containing a Tuesday",
+			date:      time.Date(2026, 11, 3, 15, 30, 0, 0, time.UTC),
+			weekStart: time.Monday,
+			start:     time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Week start day itself",
+			date:      time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC),
+			weekStart: time.Monday,
+			start:     time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Sunday belongs to the previous ISO week",
+			date:      time.Date(2026, 11, 8, 0, 0, 0, 0, time.UTC),
+			weekStart: time.Monday,
+			start:     time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Weeks starting on Sunday",
+			date:      time.Date(2026, 11, 8, 0, 0, 0, 0, time.UTC),
+			weekStart: time.Sunday,
+			start:     time.Date(2026, 11, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "Week spanning a month boundary",
+			date:      time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			weekStart: time.Monday,
+			start:     time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := WeekBounds(tt.date, tt.weekStart)
+			if !start.Equal(tt.start) {
+				t.Fatalf("expected week start %v, got %v", tt.start, start)
+			}
+			if !end.Equal(tt.start.AddDate(0, 0, 7)) {
+				t.Fatalf("expected week end %v, got %v", tt.start.AddDate(0, 0, 7), end)
+			}
+		})
+	}
+}
+
+func TestMonthBounds(t *testing.T) {
+	start, end := MonthBounds(time.Date(2026, 12, 17, 9, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected month bounds %v - %v", start, end)
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"github.com/skip2/go-qrcode"
+)
+
+// QRCodePNG renders text as a QR code PNG image at error correction level M, with scale pixels per module
+func QRCodePNG(text string, scale int) ([]byte, error) {
+	qr, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	// A negative size sets the pixels per module instead of the image width
+	return qr.PNG(-scale)
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"github.com/skip2/go-qrcode"
+	"image/png"
+	"testing"
+)
+
+func TestQRCodePNG(t *testing.T) {
+	t.Setenv("CHECKIN_SECRET", "test-secret")
+	token, err := GenerateCheckinToken("64b7f0c2a1b2c3d4e5f60718", "2026-11-03", 3)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	const scale = 4
+	rendered, err := QRCodePNG(token, scale)
+	if err != nil {
+		t.Fatalf("failed to render QR code: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(rendered))
+	if err != nil {
+		t.Fatalf("rendered QR code is not a valid PNG: %v", err)
+	}
+
+	// Every module of the image, read at its center, matches the symbol of the token
+	qr, err := qrcode.New(token, qrcode.Medium)
+	if err != nil {
+		t.Fatalf("failed to encode token: %v", err)
+	}
+	bitmap := qr.Bitmap()
+	if width := img.Bounds().Dx(); width != len(bitmap)*scale {
+		t.Fatalf("expected an image of %d pixels, got %d", len(bitmap)*scale, width)
+	}
+	for y, row := range bitmap {
+		for x, dark := range row {
+			r, _, _, _ := img.At(x*scale+scale/2, y*scale+scale/2).RGBA()
+			if (r < 0x8000) != dark {
+				t.Fatalf("module (%d, %d) does not match the token", x, y)
+			}
+		}
+	}
+}